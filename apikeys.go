@@ -0,0 +1,231 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultAPIKeyPrefixLength = 12
+
+// ErrAPIKeysInvalid is returned when an API key file can not be parsed.
+var ErrAPIKeysInvalid = errors.New("invalid API key file")
+
+// APIKey is an entry in an API key file.
+//
+// The file is YAML or JSON with the entries in a "keys" list:
+//
+//	keys:
+//	  - prefix: pk_live_3f9a
+//	    hash: b59b059be2c4c22068b0bdfb705acb0b...
+//	    owner: partner-a
+//	    scopes: [orders.read]
+//	    expires_at: 2026-01-01T00:00:00Z
+type APIKey struct {
+	// Prefix is the non-secret start of the key used to look it up, it must be APIKeysConfig.PrefixLength long.
+	Prefix string `json:"prefix" yaml:"prefix"`
+
+	// Hash is the hex encoded HMAC-SHA256 of the key, see HashAPIKey.
+	Hash string `json:"hash" yaml:"hash"`
+
+	// Owner is the principal subject for the key.
+	Owner string `json:"owner" yaml:"owner"`
+
+	// Scopes are the principal scopes for the key.
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+
+	// Roles are the principal roles for the key.
+	Roles []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+
+	// ExpiresAt is when the key stops being accepted, the key does not expire when zero.
+	ExpiresAt time.Time `json:"expires_at,omitzero" yaml:"expires_at,omitempty"`
+
+	// Disabled keys are not accepted.
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// APIKeysConfig is the configuration for NewAPIKeys and NewAPIKeysFile.
+type APIKeysConfig struct {
+	// Secret is the HMAC key the key hashes were created with, it should not be stored in the key file. Files
+	// containing keys are rejected when it is empty.
+	Secret []byte
+
+	// PrefixLength is the length of the key prefix used for lookups, defaults to 12.
+	PrefixLength int
+
+	// ReloadInterval is the interval the file is checked for changes at, the file is only read once when zero.
+	ReloadInterval time.Duration
+
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// APIKeys verifies bearer API keys against a file of hashed keys.
+//
+// Keys are looked up by their prefix and compared by HMAC in constant time, the file never contains the keys
+// themselves. The file is swapped atomically on reload so in-flight verifications use the keys loaded when they
+// started.
+type APIKeys struct {
+	cfg  APIKeysConfig
+	keys atomic.Pointer[map[string][]apiKeyEntry]
+	file *fileReloader
+}
+
+type apiKeyEntry struct {
+	APIKey
+
+	sum []byte
+}
+
+// HashAPIKey returns the hex encoded HMAC-SHA256 of the key for the APIKey Hash field.
+func HashAPIKey(secret []byte, key string) string {
+	return hex.EncodeToString(apiKeySum(secret, key))
+}
+
+func apiKeySum(secret []byte, key string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(key))
+
+	return mac.Sum(nil)
+}
+
+// NewAPIKeys returns an APIKeys verifier for the API key file content.
+func NewAPIKeys(r io.Reader, cfg APIKeysConfig) (*APIKeys, error) {
+	k := newAPIKeys(cfg)
+
+	if err := k.parse(r); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// NewAPIKeysFile returns an APIKeys verifier for the file.
+//
+// The background reload started when ReloadInterval is set runs until ctx is cancelled or Close is called.
+func NewAPIKeysFile(ctx context.Context, filename string, cfg APIKeysConfig) (*APIKeys, error) {
+	k := newAPIKeys(cfg)
+	k.file = newFileReloader(filename, "API key", k.parse)
+
+	if err := k.file.load(); err != nil {
+		return nil, err
+	}
+
+	k.file.start(ctx, cfg.ReloadInterval)
+
+	return k, nil
+}
+
+func newAPIKeys(cfg APIKeysConfig) *APIKeys {
+	if cfg.PrefixLength <= 0 {
+		cfg.PrefixLength = defaultAPIKeyPrefixLength
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &APIKeys{cfg: cfg}
+}
+
+// VerifyBearer is an AuthVerifyBearerErrFunc that checks the key against the file, the principal has the owner,
+// scopes, roles and expiry of the key.
+func (k *APIKeys) VerifyBearer(ctx context.Context, token string) (context.Context, string, bool, error) {
+	if len(token) < k.cfg.PrefixLength {
+		return ctx, "", false, ErrInvalidCredentials
+	}
+
+	sum := apiKeySum(k.cfg.Secret, token)
+	prefix := token[:k.cfg.PrefixLength]
+
+	candidates := (*k.keys.Load())[prefix]
+
+	// every candidate is compared so the time taken does not reveal which one matched.
+	var match *apiKeyEntry
+
+	for i := range candidates {
+		if subtle.ConstantTimeCompare(sum, candidates[i].sum) == 1 {
+			match = &candidates[i]
+		}
+	}
+
+	if match == nil || match.Disabled || (!match.ExpiresAt.IsZero() && !k.cfg.Now().Before(match.ExpiresAt)) {
+		return ctx, "", false, ErrInvalidCredentials
+	}
+
+	ctx = NewContextWithPrincipal(ctx, &Principal{
+		Subject:   match.Owner,
+		Online:    true,
+		ExpiresAt: match.ExpiresAt,
+		Scopes:    match.Scopes,
+		Roles:     match.Roles,
+		Claims:    map[string]any{"key_prefix": match.Prefix},
+	})
+
+	return ctx, match.Owner, true, nil
+}
+
+// Reload reads the file, the existing keys are retained if the file can not be read.
+func (k *APIKeys) Reload() error {
+	if k.file == nil {
+		return nil
+	}
+
+	return k.file.load()
+}
+
+// Close stops the background reload.
+func (k *APIKeys) Close() {
+	if k.file != nil {
+		k.file.close()
+	}
+}
+
+func (k *APIKeys) parse(r io.Reader) error {
+	var file struct {
+		Keys []APIKey `yaml:"keys"`
+	}
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %w", ErrAPIKeysInvalid, err)
+	}
+
+	keys := map[string][]apiKeyEntry{}
+
+	for i, key := range file.Keys {
+		if len(key.Prefix) != k.cfg.PrefixLength {
+			return fmt.Errorf("%w: key %d prefix must be %d characters", ErrAPIKeysInvalid, i, k.cfg.PrefixLength)
+		}
+
+		if key.Owner == "" {
+			return fmt.Errorf("%w: key %d is missing an owner", ErrAPIKeysInvalid, i)
+		}
+
+		if len(k.cfg.Secret) == 0 {
+			return fmt.Errorf("%w: key %d can not be verified without a secret", ErrAPIKeysInvalid, i)
+		}
+
+		sum, err := hex.DecodeString(key.Hash)
+		if err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("%w: key %d hash is not a hex encoded HMAC-SHA256", ErrAPIKeysInvalid, i)
+		}
+
+		keys[key.Prefix] = append(keys[key.Prefix], apiKeyEntry{APIKey: key, sum: sum})
+	}
+
+	k.keys.Store(&keys)
+
+	return nil
+}
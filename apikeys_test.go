@@ -0,0 +1,207 @@
+package grpcauth_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/metadata"
+)
+
+const testAPIKeysYAML = `keys:
+  - prefix: pk_live_3f9a
+    hash: b59b059be2c4c22068b0bdfb705acb0b02f7bed1881e27c0955460481a8e60e0
+    owner: partner-a
+    scopes: [orders.read, orders.write]
+    roles: [partner]
+  - prefix: pk_test_0000
+    hash: ca0730aed5e7e4b6a9c7aab4abc07732879aef49bd33c40c6dd552e8eef901ae
+    owner: partner-disabled
+    disabled: true
+  - prefix: pk_test_1111
+    hash: dfc28fd437ae1527d4d5e38e08b1f2607808024d872c6c114ed34d339b918f87
+    owner: partner-expired
+    expires_at: 2025-01-01T00:00:00Z
+`
+
+const testAPIKeysJSON = `{"keys": [
+  {
+    "prefix": "pk_live_77c1",
+    "hash": "cdbb9dc3e3e02a99afaeda8b34091125cb12b5ecc3fc09cf4d38edaeceeb437f",
+    "owner": "partner-b",
+    "expires_at": "2026-01-01T00:00:00Z"
+  }
+]}`
+
+func testAPIKeysConfig() grpcauth.APIKeysConfig {
+	return grpcauth.APIKeysConfig{
+		Secret: []byte("test-secret"),
+		Now:    func() time.Time { return testJWTNow },
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	expected := "b59b059be2c4c22068b0bdfb705acb0b02f7bed1881e27c0955460481a8e60e0"
+	if v := grpcauth.HashAPIKey([]byte("test-secret"), "pk_live_3f9aS3cr3tPartnerA"); v != expected {
+		t.Errorf("expected hash to be '%s', received '%s'", expected, v)
+	}
+}
+
+func TestAPIKeys_VerifyBearer(t *testing.T) {
+	tests := []struct {
+		name, token, expectedUser string
+	}{
+		{"valid", "pk_live_3f9aS3cr3tPartnerA", "partner-a"},
+		{"wrong secret part", "pk_live_3f9aS3cr3tPartnerB", ""},
+		{"unknown prefix", "pk_live_ffffS3cr3tPartnerA", ""},
+		{"too short", "pk_live", ""},
+		{"disabled", "pk_test_0000S3cr3tDisabled", ""},
+		{"expired", "pk_test_1111S3cr3tExpired", ""},
+	}
+
+	k, err := grpcauth.NewAPIKeys(strings.NewReader(testAPIKeysYAML), testAPIKeysConfig())
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, user, online, err := k.VerifyBearer(context.Background(), tt.token)
+			if tt.expectedUser == "" {
+				if !errors.Is(err, grpcauth.ErrInvalidCredentials) {
+					t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+				}
+
+				return
+			}
+
+			if err != nil || user != tt.expectedUser || !online {
+				t.Fatalf("expected '%s' to be verified online, received '%s' '%t' '%v'", tt.expectedUser, user, online, err)
+			}
+
+			p, ok := grpcauth.PrincipalFromContext(ctx)
+			prefix, _ := p.Claim("key_prefix")
+
+			if !ok || !p.HasScope("orders.write") || !p.HasRole("partner") || prefix != "pk_live_3f9a" {
+				t.Errorf("expected principal to have the key scopes, roles and prefix, received '%+v'", p)
+			}
+		})
+	}
+}
+
+func TestAPIKeys_JSON(t *testing.T) {
+	k, err := grpcauth.NewAPIKeys(strings.NewReader(testAPIKeysJSON), testAPIKeysConfig())
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	ctx, user, _, err := k.VerifyBearer(context.Background(), "pk_live_77c1S3cr3tPartnerB")
+	if err != nil || user != "partner-b" {
+		t.Fatalf("expected 'partner-b' to be verified, received '%s' '%v'", user, err)
+	}
+
+	expected := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if p, _ := grpcauth.PrincipalFromContext(ctx); !p.ExpiresAt.Equal(expected) {
+		t.Errorf("expected principal expiry to be '%s', received '%s'", expected, p.ExpiresAt)
+	}
+}
+
+func TestAPIKeys_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": "keys:\n  - prefix: pk_live_3f9a\n    secret: plaintext\n",
+		"short prefix": "keys:\n  - prefix: pk\n    owner: a\n    hash: " +
+			"b59b059be2c4c22068b0bdfb705acb0b02f7bed1881e27c0955460481a8e60e0\n",
+		"missing owner": "keys:\n  - prefix: pk_live_3f9a\n    hash: " +
+			"b59b059be2c4c22068b0bdfb705acb0b02f7bed1881e27c0955460481a8e60e0\n",
+		"invalid hash": "keys:\n  - prefix: pk_live_3f9a\n    owner: a\n    hash: plaintext\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := grpcauth.NewAPIKeys(strings.NewReader(content), testAPIKeysConfig()); !errors.Is(
+				err,
+				grpcauth.ErrAPIKeysInvalid,
+			) {
+				t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrAPIKeysInvalid, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeys_MissingSecret(t *testing.T) {
+	cfg := testAPIKeysConfig()
+	cfg.Secret = nil
+
+	if _, err := grpcauth.NewAPIKeys(strings.NewReader(testAPIKeysYAML), cfg); !errors.Is(
+		err,
+		grpcauth.ErrAPIKeysInvalid,
+	) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrAPIKeysInvalid, err)
+	}
+}
+
+func TestAPIKeys_FileReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(filename, []byte(testAPIKeysJSON), 0o600); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	cfg := testAPIKeysConfig()
+	cfg.ReloadInterval = 10 * time.Millisecond
+
+	k, err := grpcauth.NewAPIKeysFile(context.Background(), filename, cfg)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+	defer k.Close()
+
+	if _, _, _, err := k.VerifyBearer(context.Background(), "pk_live_3f9aS3cr3tPartnerA"); err == nil {
+		t.Error("expected error to be returned before reload")
+	}
+
+	if err := os.WriteFile(filename, []byte(testAPIKeysYAML), 0o600); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, _, _, err := k.VerifyBearer(context.Background(), "pk_live_3f9aS3cr3tPartnerA"); err == nil {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, _, _, err := k.VerifyBearer(context.Background(), "pk_live_3f9aS3cr3tPartnerA"); err != nil {
+		t.Errorf("expected error to be nil after reload, returned '%v'", err)
+	}
+
+	if _, _, _, err := k.VerifyBearer(context.Background(), "pk_live_77c1S3cr3tPartnerB"); err == nil {
+		t.Error("expected removed key to be rejected after reload")
+	}
+}
+
+func TestAPIKeys_VerifyAuthorization(t *testing.T) {
+	k, err := grpcauth.NewAPIKeys(strings.NewReader(testAPIKeysYAML), testAPIKeysConfig())
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Bearer pk_live_3f9aS3cr3tPartnerA"},
+	})
+
+	authCtx, err := grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewBearerErrAuthenticator(k.VerifyBearer))(ctx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "partner-a" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "partner-a", v)
+	}
+}
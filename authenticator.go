@@ -0,0 +1,129 @@
+package grpcauth
+
+import (
+	"context"
+	"strings"
+)
+
+// Authenticator verifies the credentials supplied with a single authorization scheme.
+type Authenticator interface {
+	// Scheme returns the authorization scheme handled by the authenticator, it is matched case-insensitively.
+	Scheme() string
+
+	// Authenticate verifies the credentials that followed the scheme in the authorization header and returns
+	// the context to use for the remainder of the request.
+	Authenticate(ctx context.Context, credentials string) (context.Context, error)
+}
+
+// AuthenticateFunc is the function signature used by NewAuthenticator.
+type AuthenticateFunc = func(context.Context, string) (context.Context, error)
+
+// NewAuthenticator returns an Authenticator for the scheme that calls authFunc to verify the credentials.
+func NewAuthenticator(scheme string, authFunc AuthenticateFunc) Authenticator {
+	return &funcAuthenticator{
+		scheme:   scheme,
+		authFunc: authFunc,
+	}
+}
+
+type funcAuthenticator struct {
+	scheme   string
+	authFunc AuthenticateFunc
+}
+
+func (a *funcAuthenticator) Scheme() string {
+	return a.scheme
+}
+
+func (a *funcAuthenticator) Authenticate(ctx context.Context, credentials string) (context.Context, error) {
+	return a.authFunc(ctx, credentials)
+}
+
+// NewBasicAuthenticator returns the built-in Authenticator for the HTTP Basic authorization scheme.
+func NewBasicAuthenticator(basicAuth AuthVerifyBasicFunc) Authenticator {
+	return NewBasicErrAuthenticator(BasicErrFunc(basicAuth))
+}
+
+// NewBasicErrAuthenticator returns the built-in Authenticator for the HTTP Basic authorization scheme using a
+// verification function that returns errors.
+func NewBasicErrAuthenticator(basicAuth AuthVerifyBasicErrFunc) Authenticator {
+	return &BasicAuthenticator{
+		verify: basicAuth,
+	}
+}
+
+// BasicAuthenticator decodes HTTP Basic credentials and verifies them with an AuthVerifyBasicErrFunc.
+type BasicAuthenticator struct {
+	verify AuthVerifyBasicErrFunc
+}
+
+// Scheme returns "Basic".
+func (a *BasicAuthenticator) Scheme() string {
+	return "Basic"
+}
+
+// Authenticate verifies the base64 encoded username and password.
+func (a *BasicAuthenticator) Authenticate(ctx context.Context, credentials string) (context.Context, error) {
+	return verifyAuthBasic(ctx, a.verify, credentials)
+}
+
+// NewBearerAuthenticator returns the built-in Authenticator for the HTTP Bearer authorization scheme.
+func NewBearerAuthenticator(bearerAuth AuthVerifyBearerFunc) Authenticator {
+	return NewBearerErrAuthenticator(BearerErrFunc(bearerAuth))
+}
+
+// NewBearerErrAuthenticator returns the built-in Authenticator for the HTTP Bearer authorization scheme using a
+// verification function that returns errors.
+func NewBearerErrAuthenticator(bearerAuth AuthVerifyBearerErrFunc) Authenticator {
+	return &BearerAuthenticator{
+		verify: bearerAuth,
+	}
+}
+
+// BearerAuthenticator verifies HTTP Bearer tokens with an AuthVerifyBearerErrFunc.
+type BearerAuthenticator struct {
+	verify AuthVerifyBearerErrFunc
+}
+
+// Scheme returns "Bearer".
+func (a *BearerAuthenticator) Scheme() string {
+	return "Bearer"
+}
+
+// Authenticate verifies the bearer token.
+func (a *BearerAuthenticator) Authenticate(ctx context.Context, credentials string) (context.Context, error) {
+	return verifyAuthBearer(ctx, a.verify, credentials)
+}
+
+// VerifyAuthenticatorsFunc returns a function that can be used to verify the authentication on a gRPC request
+// using the supplied authenticators.
+//
+// The first authorization header with a scheme matching one of the authenticators is verified, headers using
+// unknown schemes are ignored. When more than one authenticator is supplied for a scheme the last one is used.
+//
+//nolint:mnd // expected set length based on format.
+func VerifyAuthenticatorsFunc(authenticators ...Authenticator) func(ctx context.Context) (context.Context, error) {
+	schemes := make(map[string]Authenticator, len(authenticators))
+	for _, a := range authenticators {
+		if a == nil {
+			continue
+		}
+
+		schemes[strings.ToLower(a.Scheme())] = a
+	}
+
+	return func(ctx context.Context) (context.Context, error) {
+		for _, auth := range getHeadersFromContext(ctx) {
+			r := re.FindStringSubmatch(auth)
+			if len(r) < 3 {
+				continue
+			}
+
+			if a, ok := schemes[strings.ToLower(r[1])]; ok {
+				return a.Authenticate(ctx, r[2])
+			}
+		}
+
+		return ctx, credentialsMissing("authentication missing")
+	}
+}
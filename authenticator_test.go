@@ -0,0 +1,96 @@
+package grpcauth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func apiKeyAuthenticator() grpcauth.Authenticator {
+	return grpcauth.NewAuthenticator("ApiKey", func(ctx context.Context, key string) (context.Context, error) {
+		if key != "valid-api-key" {
+			return ctx, status.Error(codes.Unauthenticated, "authentication failed with ApiKey authorization scheme")
+		}
+
+		return context.WithValue(ctx, grpcauth.Username, "api-key-user"), nil
+	})
+}
+
+func TestAuthenticators_CustomScheme(t *testing.T) {
+	tests := []struct {
+		name, header, user string
+		expectedPass       bool
+	}{
+		{"custom scheme with valid key", "ApiKey valid-api-key", "api-key-user", true},
+		{"custom scheme is case-insensitive", "apikey valid-api-key", "api-key-user", true},
+		{"custom scheme with invalid key", "ApiKey invalid-api-key", "", false},
+		{"built-in bearer still works", "Bearer valid-online-token", "online-user", true},
+		{
+			"built-in basic still works",
+			"Basic " + base64.StdEncoding.EncodeToString([]byte("valid-user:valid-pass")),
+			"valid-user",
+			true,
+		},
+		{"unknown scheme", "HMAC-SHA256 valid-api-key", "", false},
+	}
+
+	authFunc := grpcauth.VerifyAuthenticatorsFunc(
+		grpcauth.NewBasicAuthenticator(basicAuthFunc),
+		grpcauth.NewBearerAuthenticator(bearerAuthFunc),
+		apiKeyAuthenticator(),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+				"authorization": []string{tt.header},
+			})
+
+			authCtx, err := authFunc(ctx)
+			if tt.expectedPass && err != nil {
+				t.Errorf("expected error to be nil, returned '%v'", err)
+			}
+
+			if !tt.expectedPass && err == nil {
+				t.Error("expected error to be returned, but error returned nil")
+			}
+
+			if tt.expectedPass {
+				if v := authCtx.Value(grpcauth.Username); v != tt.user {
+					t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", tt.user, v)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticators_SkipsUnknownSchemes(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"DPoP some-token", "ApiKey valid-api-key"},
+	})
+
+	authCtx, err := grpcauth.VerifyAuthenticatorsFunc(apiKeyAuthenticator())(ctx)
+	if err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "api-key-user" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "api-key-user", v)
+	}
+}
+
+func TestAuthenticators_NoAuthenticators(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Bearer valid-online-token"},
+	})
+
+	_, err := grpcauth.VerifyAuthenticatorsFunc()(ctx)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected error code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
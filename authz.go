@@ -0,0 +1,132 @@
+package grpcauth
+
+import (
+	"context"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Requirement is the roles and scopes the authenticated principal must have to call a method.
+type Requirement struct {
+	// Roles allows the principal when it has any of the roles, any role is allowed when empty.
+	Roles []string
+
+	// Scopes allows the principal when it has all of the scopes.
+	Scopes []string
+
+	// Public allows requests without an authenticated principal (eg. methods with AuthPublic or AuthOptional
+	// modes), principals that are present must still satisfy the roles and scopes.
+	Public bool
+}
+
+// Allows returns true if the principal satisfies the requirement, a nil principal is only allowed by public
+// requirements without roles or scopes.
+func (r Requirement) Allows(p *Principal) bool {
+	if p == nil {
+		return r.Public && len(r.Roles) == 0 && len(r.Scopes) == 0
+	}
+
+	if len(r.Roles) > 0 && !slices.ContainsFunc(r.Roles, p.HasRole) {
+		return false
+	}
+
+	for _, scope := range r.Scopes {
+		if !p.HasScope(scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AuthorizationPolicy maps full gRPC method names to the roles and scopes required to call them, it is evaluated
+// after authentication against the authenticated principal.
+//
+// Rules are matched in the same way as MethodPolicy, exact matches take precedence followed by patterns in the
+// order they were added. Requests that are not allowed are rejected with codes.PermissionDenied, requests without a
+// principal are rejected with codes.Unauthenticated.
+//
+// An AuthorizationPolicy should be fully configured before it is used to serve requests.
+type AuthorizationPolicy struct {
+	denyByDefault bool
+	rules         methodRules[Requirement]
+}
+
+// NewAuthorizationPolicy returns a new AuthorizationPolicy, when denyByDefault is true methods that do not match a
+// rule are rejected, otherwise they only require an authenticated principal unless the MethodPolicy mode allowed the
+// request through unauthenticated (AuthPublic or AuthOptional).
+func NewAuthorizationPolicy(denyByDefault bool) *AuthorizationPolicy {
+	return &AuthorizationPolicy{
+		denyByDefault: denyByDefault,
+		rules:         newMethodRules[Requirement](),
+	}
+}
+
+// Set adds a rule for the full method name or pattern, patterns use the syntax of path.Match.
+func (p *AuthorizationPolicy) Set(pattern string, req Requirement) error {
+	return p.rules.set(pattern, req)
+}
+
+// Requirement returns the requirement for the full method name and if a rule matched.
+func (p *AuthorizationPolicy) Requirement(fullMethod string) (Requirement, bool) {
+	return p.rules.match(fullMethod)
+}
+
+// Authorize checks the principal from the context is allowed to call the full method name.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func (p *AuthorizationPolicy) Authorize(ctx context.Context, fullMethod string) error {
+	req, ok := p.Requirement(fullMethod)
+	if !ok {
+		if p.denyByDefault {
+			return status.Error(codes.PermissionDenied, "method is not authorized")
+		}
+
+		req.Public = allowedUnauthenticated(ctx)
+	}
+
+	return authorizeRequirement(ctx, req)
+}
+
+// authorizeRequirement checks the principal from the context satisfies the requirement.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func authorizeRequirement(ctx context.Context, req Requirement) error {
+	pr, _ := PrincipalFromContext(ctx)
+	if req.Allows(pr) {
+		return nil
+	}
+
+	if pr == nil {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	return status.Error(codes.PermissionDenied, "principal is not authorized")
+}
+
+// UnaryServerInterceptor returns an interceptor that authorizes unary requests, it must be installed after the
+// authentication interceptor.
+func (p *AuthorizationPolicy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := p.Authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that authorizes streaming requests, it must be installed after
+// the authentication interceptor.
+func (p *AuthorizationPolicy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := p.Authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
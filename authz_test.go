@@ -0,0 +1,165 @@
+package grpcauth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testAuthzServerStream struct {
+	grpc.ServerStream
+
+	ctx context.Context //nolint:containedctx // test code
+}
+
+func (s *testAuthzServerStream) Context() context.Context { return s.ctx }
+
+func testAuthorizationPolicy(t *testing.T, denyByDefault bool) *grpcauth.AuthorizationPolicy {
+	t.Helper()
+
+	p := grpcauth.NewAuthorizationPolicy(denyByDefault)
+
+	for pattern, req := range map[string]grpcauth.Requirement{
+		"/grpcauth.test.Test/TestOnline":   {Roles: []string{"admin", "operator"}},
+		"/grpcauth.test.Test/TestOffline":  {Scopes: []string{"read", "write"}},
+		"/grpcauth.test.Test/*":            {Roles: []string{"admin"}, Scopes: []string{"read"}},
+		grpcauth.HealthMethods:             {Public: true},
+		"/grpcauth.test.Other/Unprotected": {},
+	} {
+		if err := p.Set(pattern, req); err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+	}
+
+	return p
+}
+
+func TestAuthorizationPolicy_Authorize(t *testing.T) {
+	admin := &grpcauth.Principal{Subject: "admin", Roles: []string{"admin"}, Scopes: []string{"read"}}
+	operator := &grpcauth.Principal{Subject: "operator", Roles: []string{"operator"}}
+	writer := &grpcauth.Principal{Subject: "writer", Scopes: []string{"read", "write"}}
+
+	tests := []struct {
+		name          string
+		method        string
+		principal     *grpcauth.Principal
+		denyByDefault bool
+		expectedCode  codes.Code
+	}{
+		{"any role", "/grpcauth.test.Test/TestOnline", operator, false, codes.OK},
+		{"missing role", "/grpcauth.test.Test/TestOnline", writer, false, codes.PermissionDenied},
+		{"all scopes", "/grpcauth.test.Test/TestOffline", writer, false, codes.OK},
+		{"missing scope", "/grpcauth.test.Test/TestOffline", admin, false, codes.PermissionDenied},
+		{"pattern", "/grpcauth.test.Test/TestOther", admin, false, codes.OK},
+		{"pattern missing role", "/grpcauth.test.Test/TestOther", writer, false, codes.PermissionDenied},
+		{"unauthenticated", "/grpcauth.test.Test/TestOnline", nil, false, codes.Unauthenticated},
+		{"public", "/grpc.health.v1.Health/Check", nil, true, codes.OK},
+		{"authenticated only", "/grpcauth.test.Other/Unprotected", writer, true, codes.OK},
+		{"unmapped allowed", "/grpcauth.test.Other/Unmapped", writer, false, codes.OK},
+		{"unmapped unauthenticated", "/grpcauth.test.Other/Unmapped", nil, false, codes.Unauthenticated},
+		{"unmapped denied", "/grpcauth.test.Other/Unmapped", admin, true, codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = grpcauth.NewContextWithPrincipal(ctx, tt.principal)
+			}
+
+			err := testAuthorizationPolicy(t, tt.denyByDefault).Authorize(ctx, tt.method)
+			if status.Code(err) != tt.expectedCode {
+				t.Errorf("expected status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestAuthorizationPolicy_MethodPolicy(t *testing.T) {
+	mp := grpcauth.NewMethodPolicy(grpcauth.AuthRequired)
+	_ = mp.Set("/grpcauth.test.Other/Public", grpcauth.AuthPublic)
+	_ = mp.Set("/grpcauth.test.Other/Optional", grpcauth.AuthOptional)
+	_ = mp.Set("/grpcauth.test.Test/TestOnline", grpcauth.AuthPublic)
+
+	authFunc := mp.AuthFunc(grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc))
+
+	// methods without a rule are allowed without a principal when the method policy allowed them through.
+	tests := []struct {
+		name         string
+		method       string
+		expectedCode codes.Code
+	}{
+		{"public", "/grpcauth.test.Other/Public", codes.OK},
+		{"optional", "/grpcauth.test.Other/Optional", codes.OK},
+		{"required", "/grpcauth.test.Other/Required", codes.Unauthenticated},
+		{"public with rule", "/grpcauth.test.Test/TestOnline", codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := authFunc(incomingContext(tt.method, nil))
+			if err == nil {
+				err = testAuthorizationPolicy(t, false).Authorize(ctx, tt.method)
+			}
+
+			if status.Code(err) != tt.expectedCode {
+				t.Errorf("expected status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestAuthorizationPolicy_Set_InvalidPattern(t *testing.T) {
+	if err := grpcauth.NewAuthorizationPolicy(true).Set("/grpcauth.test.Test/[", grpcauth.Requirement{}); err == nil {
+		t.Error("expected error to be returned, but error returned nil")
+	}
+}
+
+func TestAuthorizationPolicy_Interceptors(t *testing.T) {
+	p := testAuthorizationPolicy(t, true)
+	ctx := grpcauth.NewContextWithPrincipal(context.Background(), &grpcauth.Principal{
+		Subject: "operator",
+		Roles:   []string{"operator"},
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		expectedCode codes.Code
+	}{
+		{"allowed", "/grpcauth.test.Test/TestOnline", codes.OK},
+		{"denied", "/grpcauth.test.Test/TestOffline", codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+
+			_, err := p.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(context.Context, any) (any, error) {
+					called = true
+
+					return struct{}{}, nil
+				})
+			if status.Code(err) != tt.expectedCode || called != (tt.expectedCode == codes.OK) {
+				t.Errorf("expected unary status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+
+			called = false
+
+			err = p.StreamServerInterceptor()(nil, &testAuthzServerStream{ctx: ctx},
+				&grpc.StreamServerInfo{FullMethod: tt.method}, func(any, grpc.ServerStream) error {
+					called = true
+
+					return nil
+				})
+			if status.Code(err) != tt.expectedCode || called != (tt.expectedCode == codes.OK) {
+				t.Errorf("expected stream status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package grpcauth
+
+import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultBearerCacheSize = 10000
+
+// BearerCacheConfig is the configuration for NewBearerCache.
+type BearerCacheConfig struct {
+	// TTL is how long successful verifications are cached for, it is further bounded by Principal.ExpiresAt when
+	// set by the verifier.
+	TTL time.Duration
+
+	// NegativeTTL is how long failed verifications (errors wrapping ErrInvalidCredentials) are cached for, negative
+	// caching is disabled when zero. Other errors are never cached.
+	NegativeTTL time.Duration
+
+	// MaxEntries is the maximum number of cached results, the least recently used result is evicted when full,
+	// defaults to 10000.
+	MaxEntries int
+
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// BearerCache caches the results of a bearer token verification function.
+//
+// Tokens are never stored, results are keyed by an HMAC of the token using a random key generated for each cache.
+//
+// Principals returned from the cache are marked offline, so methods requiring online verification still call the
+// verifier. Context values other than the principal that were added by the verifier are not cached.
+//
+// Results are keyed by the token alone, so the wrapped verification function must only depend on the token. Checks
+// bound to the connection or request (eg. CertificateBoundFunc, ChannelBindingFunc or verifiers that read the peer
+// or metadata) must wrap BearerCache.VerifyBearer rather than be wrapped by it, otherwise a failed check from one
+// connection is negatively cached and rejects the token on every connection.
+type BearerCache struct {
+	verify AuthVerifyBearerErrFunc
+	cfg    BearerCacheConfig
+	hasher *credentialHasher
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List
+}
+
+type bearerCacheEntry struct {
+	key       [sha256.Size]byte
+	user      string
+	principal *Principal
+	err       error
+	expires   time.Time
+}
+
+// NewBearerCache returns a new BearerCache wrapping the verification function.
+func NewBearerCache(verify AuthVerifyBearerErrFunc, cfg BearerCacheConfig) *BearerCache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultBearerCacheSize
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &BearerCache{
+		verify:  verify,
+		cfg:     cfg,
+		hasher:  newCredentialHasher(),
+		entries: map[[sha256.Size]byte]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// VerifyBearer is an AuthVerifyBearerErrFunc that returns the cached result for the token, calling the wrapped
+// verification function when it is not cached.
+func (c *BearerCache) VerifyBearer(ctx context.Context, token string) (context.Context, string, bool, error) {
+	key := c.hasher.sum(token)
+
+	if entry, ok := c.get(key); ok {
+		if entry.err != nil {
+			return ctx, "", false, entry.err
+		}
+
+		if entry.principal != nil {
+			p := *entry.principal
+			p.Online = false
+			ctx = NewContextWithPrincipal(ctx, &p)
+		}
+
+		return ctx, entry.user, false, nil
+	}
+
+	outCtx, user, online, err := c.verify(ctx, token)
+
+	switch {
+	case err == nil:
+		entry := &bearerCacheEntry{key: key, user: user, expires: c.cfg.Now().Add(c.cfg.TTL)}
+		if p, ok := PrincipalFromContext(outCtx); ok {
+			entry.principal = p
+
+			if !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(entry.expires) {
+				entry.expires = p.ExpiresAt
+			}
+		}
+
+		if c.cfg.TTL > 0 {
+			c.set(entry)
+		}
+	case errors.Is(err, ErrInvalidCredentials) && c.cfg.NegativeTTL > 0:
+		c.set(&bearerCacheEntry{key: key, err: err, expires: c.cfg.Now().Add(c.cfg.NegativeTTL)})
+	}
+
+	return outCtx, user, online, err
+}
+
+// Len returns the number of cached results.
+func (c *BearerCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Purge removes all cached results.
+func (c *BearerCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[[sha256.Size]byte]*list.Element{}
+	c.lru.Init()
+}
+
+func (c *BearerCache) get(key [sha256.Size]byte) (*bearerCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry, _ := el.Value.(*bearerCacheEntry)
+	if !c.cfg.Now().Before(entry.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+
+	return entry, true
+}
+
+func (c *BearerCache) set(entry *bearerCacheEntry) {
+	if !c.cfg.Now().Before(entry.expires) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[entry.key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+
+		return
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.cfg.MaxEntries {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+
+		if old, ok := el.Value.(*bearerCacheEntry); ok {
+			delete(c.entries, old.key)
+		}
+	}
+}
+
+// credentialHasher produces keys for credentials using an HMAC with a random key, so credentials are never
+// stored and the keys can not be precomputed.
+type credentialHasher struct {
+	key []byte
+}
+
+func newCredentialHasher() *credentialHasher {
+	key := make([]byte, sha256.Size)
+	_, _ = rand.Read(key)
+
+	return &credentialHasher{key: key}
+}
+
+// sum returns the HMAC of the parts, each part is length prefixed so the parts can not be ambiguous.
+func (h *credentialHasher) sum(parts ...string) [sha256.Size]byte {
+	mac := hmac.New(sha256.New, h.key)
+
+	for _, p := range parts {
+		_ = binary.Write(mac, binary.BigEndian, uint64(len(p)))
+		mac.Write([]byte(p))
+	}
+
+	var key [sha256.Size]byte
+	copy(key[:], mac.Sum(nil))
+
+	return key
+}
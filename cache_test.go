@@ -0,0 +1,236 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/metadata"
+)
+
+type countingBearerVerifier struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (v *countingBearerVerifier) VerifyBearer(ctx context.Context, token string) (context.Context, string, bool, error) {
+	v.calls.Add(1)
+
+	if v.err != nil {
+		return ctx, "", false, v.err
+	}
+
+	if token == "invalid-token" {
+		return ctx, "", false, grpcauth.ErrInvalidCredentials
+	}
+
+	ctx = grpcauth.NewContextWithPrincipal(ctx, &grpcauth.Principal{
+		Subject:   "cached-" + token,
+		Online:    true,
+		Scopes:    []string{"read"},
+		ExpiresAt: testJWTNow.Add(time.Hour),
+	})
+
+	return ctx, "cached-" + token, true, nil
+}
+
+func TestBearerCache_Positive(t *testing.T) {
+	verifier := &countingBearerVerifier{}
+	now := testJWTNow
+	cache := grpcauth.NewBearerCache(verifier.VerifyBearer, grpcauth.BearerCacheConfig{
+		TTL: time.Minute,
+		Now: func() time.Time { return now },
+	})
+
+	_, user, online, err := cache.VerifyBearer(context.Background(), "token")
+	if err != nil || user != "cached-token" || !online {
+		t.Errorf("expected first verification to succeed online, received '%s' '%t' '%v'", user, online, err)
+	}
+
+	ctx, user, online, err := cache.VerifyBearer(context.Background(), "token")
+	if err != nil || user != "cached-token" || online {
+		t.Errorf("expected cached verification to succeed offline, received '%s' '%t' '%v'", user, online, err)
+	}
+
+	if p, ok := grpcauth.PrincipalFromContext(ctx); !ok || p.Online || !p.HasScope("read") {
+		t.Errorf("expected cached principal to be offline with scope 'read', received '%+v'", p)
+	}
+
+	if c := verifier.calls.Load(); c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if _, _, online, _ := cache.VerifyBearer(context.Background(), "token"); !online {
+		t.Error("expected verification after TTL to be online")
+	}
+}
+
+func TestBearerCache_BoundedByExpiry(t *testing.T) {
+	verifier := &countingBearerVerifier{}
+	now := testJWTNow
+	cache := grpcauth.NewBearerCache(verifier.VerifyBearer, grpcauth.BearerCacheConfig{
+		TTL: 24 * time.Hour,
+		Now: func() time.Time { return now },
+	})
+
+	_, _, _, _ = cache.VerifyBearer(context.Background(), "token")
+	now = now.Add(2 * time.Hour)
+	_, _, _, _ = cache.VerifyBearer(context.Background(), "token")
+
+	if c := verifier.calls.Load(); c != 2 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 2, c)
+	}
+}
+
+func TestBearerCache_Negative(t *testing.T) {
+	verifier := &countingBearerVerifier{}
+	cache := grpcauth.NewBearerCache(verifier.VerifyBearer, grpcauth.BearerCacheConfig{
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+	})
+
+	for range 3 {
+		if _, _, _, err := cache.VerifyBearer(context.Background(), "invalid-token"); !errors.Is(
+			err,
+			grpcauth.ErrInvalidCredentials,
+		) {
+			t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+		}
+	}
+
+	if c := verifier.calls.Load(); c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+}
+
+func TestBearerCache_ConnectionBoundOutside(t *testing.T) {
+	cert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "bound-client"}})
+	otherCert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other-client"}})
+
+	token := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(
+		map[string]any{"cnf": map[string]any{"x5t#S256": grpcauth.CertificateThumbprint(cert)}},
+	))
+
+	cache := grpcauth.NewBearerCache(testJWTVerifier().VerifyBearer, grpcauth.BearerCacheConfig{
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+	})
+
+	// the binding must be checked outside of the cache, so a mismatch from another connection is not cached.
+	verify := grpcauth.CertificateBoundFunc(cache.VerifyBearer, grpcauth.CertificateBoundConfig{})
+
+	if _, _, _, err := verify(peerContext(context.Background(), otherCert, true), token); !errors.Is(
+		err,
+		grpcauth.ErrInvalidCredentials,
+	) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+	}
+
+	for range 2 {
+		if _, _, _, err := verify(peerContext(context.Background(), cert, true), token); err != nil {
+			t.Errorf("expected error to be nil, returned '%v'", err)
+		}
+	}
+}
+
+func TestBearerCache_NegativeDisabled(t *testing.T) {
+	verifier := &countingBearerVerifier{}
+	cache := grpcauth.NewBearerCache(verifier.VerifyBearer, grpcauth.BearerCacheConfig{TTL: time.Minute})
+
+	for range 3 {
+		_, _, _, _ = cache.VerifyBearer(context.Background(), "invalid-token")
+	}
+
+	if c := verifier.calls.Load(); c != 3 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 3, c)
+	}
+}
+
+func TestBearerCache_UnavailableNotCached(t *testing.T) {
+	verifier := &countingBearerVerifier{err: grpcauth.ErrVerifierUnavailable}
+	cache := grpcauth.NewBearerCache(verifier.VerifyBearer, grpcauth.BearerCacheConfig{
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+	})
+
+	for range 3 {
+		_, _, _, _ = cache.VerifyBearer(context.Background(), "token")
+	}
+
+	if c := verifier.calls.Load(); c != 3 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 3, c)
+	}
+
+	if l := cache.Len(); l != 0 {
+		t.Errorf("expected cache length to be '%d', received '%d'", 0, l)
+	}
+}
+
+func TestBearerCache_LRUEviction(t *testing.T) {
+	verifier := &countingBearerVerifier{}
+	cache := grpcauth.NewBearerCache(verifier.VerifyBearer, grpcauth.BearerCacheConfig{
+		TTL:        time.Minute,
+		MaxEntries: 2,
+		Now:        func() time.Time { return testJWTNow },
+	})
+
+	for i := range 3 {
+		_, _, _, _ = cache.VerifyBearer(context.Background(), fmt.Sprintf("token-%d", i))
+
+		// keep token-0 recently used.
+		_, _, _, _ = cache.VerifyBearer(context.Background(), "token-0")
+	}
+
+	if l := cache.Len(); l != 2 {
+		t.Errorf("expected cache length to be '%d', received '%d'", 2, l)
+	}
+
+	before := verifier.calls.Load()
+
+	if _, _, online, _ := cache.VerifyBearer(context.Background(), "token-0"); online {
+		t.Error("expected token-0 to be cached")
+	}
+
+	if _, _, online, _ := cache.VerifyBearer(context.Background(), "token-1"); !online {
+		t.Error("expected token-1 to be evicted")
+	}
+
+	if c := verifier.calls.Load() - before; c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+}
+
+func TestBearerCache_VerifyAuthorization(t *testing.T) {
+	verifier := &countingBearerVerifier{}
+	authFunc := grpcauth.VerifyAuthenticatorsFunc(
+		grpcauth.NewBearerErrAuthenticator(
+			grpcauth.NewBearerCache(verifier.VerifyBearer, grpcauth.BearerCacheConfig{
+				TTL: time.Minute,
+				Now: func() time.Time { return testJWTNow },
+			}).VerifyBearer,
+		),
+	)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Bearer token"},
+	})
+
+	for _, expectedOnline := range []bool{true, false} {
+		authCtx, err := authFunc(ctx)
+		if err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+
+		if v := authCtx.Value(grpcauth.Online); v != expectedOnline {
+			t.Errorf("expected context value grpcauth.Online to be '%t', received '%t'", expectedOnline, v)
+		}
+	}
+}
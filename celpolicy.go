@@ -0,0 +1,319 @@
+package grpcauth
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// CELPolicyConfig is the configuration for NewCELPolicy.
+type CELPolicyConfig struct {
+	// DenyByDefault rejects methods that do not match a rule, otherwise they only require an authenticated
+	// principal.
+	DenyByDefault bool
+
+	// EnvOptions are added to the CEL environment (eg. cel.Function declarations or ext.Strings()).
+	EnvOptions []cel.EnvOption
+
+	// Now returns the current time for the "now" variable, defaults to time.Now.
+	Now func() time.Time
+}
+
+// CELPolicy authorizes requests with CEL expressions (https://cel.dev) that must evaluate to true, it is evaluated
+// after authentication.
+//
+// Expressions have access to the variables:
+//
+//	principal  map(string, dyn)           subject, scheme, online, scopes, roles, claims, authenticated,
+//	                                      authenticated_at and expires_at of the authenticated principal
+//	method     string                     the full method name (eg. "/grpcauth.test.Test/TestOnline")
+//	peer       map(string, string)        the peer "address"
+//	metadata   map(string, list(string))  the incoming metadata
+//	now        timestamp                  the current time
+//	request    message                    the request message
+//
+// The request is typed when the methods matching the rule share a request message registered with the protobuf
+// registry, otherwise it is dyn. Expressions are type checked when they are added, evaluation errors deny the
+// request.
+//
+// For streaming methods rules are evaluated when the stream starts with the request unknown, rules that can not be
+// decided without the request are then evaluated against each message received on the stream.
+//
+// Rules are matched in the same way as MethodPolicy, exact matches take precedence followed by patterns in the
+// order they were added. A CELPolicy should be fully configured before it is used to serve requests.
+type CELPolicy struct {
+	cfg   CELPolicyConfig
+	env   *cel.Env
+	rules methodRules[celRule]
+}
+
+// celRule is a compiled rule expression.
+type celRule struct {
+	prg cel.Program
+
+	// request is true when the expression uses the "request" variable.
+	request bool
+}
+
+// NewCELPolicy returns a new CELPolicy.
+func NewCELPolicy(cfg CELPolicyConfig) (*CELPolicy, error) {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	env, err := cel.NewEnv(append([]cel.EnvOption{
+		cel.Variable("principal", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("method", cel.StringType),
+		cel.Variable("peer", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("metadata", cel.MapType(cel.StringType, cel.ListType(cel.StringType))),
+		cel.Variable("now", cel.TimestampType),
+	}, cfg.EnvOptions...)...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CEL environment: %w", err)
+	}
+
+	return &CELPolicy{
+		cfg:   cfg,
+		env:   env,
+		rules: newMethodRules[celRule](),
+	}, nil
+}
+
+// Set compiles the expression and adds it as the rule for the full method name or pattern, patterns use the syntax
+// of path.Match. The expression must type check and return a bool.
+func (p *CELPolicy) Set(pattern, expr string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid method pattern '%s': %w", pattern, err)
+	}
+
+	env, err := p.env.Extend(celRequestOptions(pattern)...)
+	if err != nil {
+		return fmt.Errorf("unable to create CEL environment for '%s': %w", pattern, err)
+	}
+
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return fmt.Errorf("invalid CEL expression for '%s': %w", pattern, iss.Err())
+	}
+
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return fmt.Errorf("invalid CEL expression for '%s': must return bool, returns %s", pattern, ast.OutputType())
+	}
+
+	rule := celRule{}
+
+	for _, ref := range ast.NativeRep().ReferenceMap() {
+		if ref.Name == "request" {
+			rule.request = true
+
+			break
+		}
+	}
+
+	var opts []cel.ProgramOption
+	if rule.request {
+		opts = append(opts, cel.EvalOptions(cel.OptPartialEval))
+	}
+
+	if rule.prg, err = env.Program(ast, opts...); err != nil {
+		return fmt.Errorf("invalid CEL expression for '%s': %w", pattern, err)
+	}
+
+	return p.rules.set(pattern, rule)
+}
+
+// Authorize evaluates the rule for the full method name against the request, req is nil when the rule is evaluated
+// without a request and rules that use the request are then denied.
+func (p *CELPolicy) Authorize(ctx context.Context, fullMethod string, req any) error {
+	return p.authorize(ctx, fullMethod, req, false)
+}
+
+// authorize evaluates the rule for the full method name, when partial is true and req is nil the request is unknown
+// and rules that can not be decided without it are allowed so they can be evaluated against each message.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func (p *CELPolicy) authorize(ctx context.Context, fullMethod string, req any, partial bool) error {
+	pr, _ := PrincipalFromContext(ctx)
+
+	rule, ok := p.rules.match(fullMethod)
+	if !ok {
+		switch {
+		case p.cfg.DenyByDefault:
+			return status.Error(codes.PermissionDenied, "method is not authorized")
+		case pr == nil:
+			return status.Error(codes.Unauthenticated, "authentication required")
+		}
+
+		return nil
+	}
+
+	vars := map[string]any{
+		"principal": celPrincipal(pr),
+		"method":    fullMethod,
+		"peer":      map[string]string{"address": ""},
+		"metadata":  map[string][]string{},
+		"now":       p.cfg.Now(),
+	}
+
+	if pe, ok := peer.FromContext(ctx); ok && pe.Addr != nil {
+		vars["peer"] = map[string]string{"address": pe.Addr.String()}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		vars["metadata"] = map[string][]string(md)
+	}
+
+	var act any = vars
+
+	switch {
+	case req != nil:
+		vars["request"] = req
+	case partial && rule.request:
+		if pa, err := cel.PartialVars(vars, cel.AttributePattern("request")); err == nil {
+			act = pa
+		}
+	}
+
+	out, _, err := rule.prg.ContextEval(ctx, act)
+	if err == nil && (out == types.True || (partial && types.IsUnknown(out))) {
+		return nil
+	}
+
+	if pr == nil {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	return status.Error(codes.PermissionDenied, "principal is not authorized")
+}
+
+// UnaryServerInterceptor returns an interceptor that authorizes unary requests, it must be installed after the
+// authentication interceptor.
+func (p *CELPolicy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := p.Authorize(ctx, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that authorizes streaming requests, it must be installed after
+// the authentication interceptor.
+//
+// Rules are evaluated when the stream starts with the request unknown, so conditions that do not depend on the
+// request are checked before the handler is called. Rules that use the request are also evaluated against each
+// message received.
+func (p *CELPolicy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := p.authorize(ss.Context(), info.FullMethod, nil, true); err != nil {
+			return err
+		}
+
+		if rule, ok := p.rules.match(info.FullMethod); ok && rule.request {
+			return handler(srv, &celServerStream{ServerStream: ss, policy: p, fullMethod: info.FullMethod})
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// celServerStream evaluates the rule against each message received on the stream.
+type celServerStream struct {
+	grpc.ServerStream
+
+	policy     *CELPolicy
+	fullMethod string
+}
+
+func (s *celServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err //nolint:wrapcheck // stream errors are returned unchanged.
+	}
+
+	return s.policy.Authorize(s.Context(), s.fullMethod, m)
+}
+
+// celPrincipal returns the principal as a CEL map, a nil principal is unauthenticated.
+func celPrincipal(p *Principal) map[string]any {
+	if p == nil {
+		return map[string]any{
+			"authenticated": false,
+			"subject":       "",
+			"scheme":        "",
+			"online":        false,
+			"scopes":        []string{},
+			"roles":         []string{},
+			"claims":        map[string]any{},
+		}
+	}
+
+	claims := p.Claims
+	if claims == nil {
+		claims = map[string]any{}
+	}
+
+	return map[string]any{
+		"authenticated":    true,
+		"subject":          p.Subject,
+		"scheme":           p.Scheme,
+		"online":           p.Online,
+		"scopes":           append([]string{}, p.Scopes...),
+		"roles":            append([]string{}, p.Roles...),
+		"claims":           claims,
+		"authenticated_at": p.AuthenticatedAt,
+		"expires_at":       p.ExpiresAt,
+	}
+}
+
+// celRequestOptions declares the "request" variable for the methods matching the pattern, it is typed when the
+// methods registered with the protobuf registry share a request message.
+func celRequestOptions(pattern string) []cel.EnvOption {
+	var (
+		inputs []protoreflect.MessageDescriptor
+		files  []any
+	)
+
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := range services.Len() {
+			methods := services.Get(i).Methods()
+			for j := range methods.Len() {
+				m := methods.Get(j)
+				if ok, _ := path.Match(pattern, "/"+string(services.Get(i).FullName())+"/"+string(m.Name())); ok {
+					inputs = append(inputs, m.Input())
+					files = append(files, m.Input().ParentFile())
+				}
+			}
+		}
+
+		return true
+	})
+
+	requestType := cel.DynType
+
+	if len(inputs) > 0 {
+		requestType = cel.ObjectType(string(inputs[0].FullName()))
+
+		for _, in := range inputs[1:] {
+			if in.FullName() != inputs[0].FullName() {
+				requestType = cel.DynType
+
+				break
+			}
+		}
+	}
+
+	return []cel.EnvOption{cel.TypeDescs(files...), cel.Variable("request", requestType)}
+}
@@ -0,0 +1,259 @@
+package grpcauth_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"github.com/dosquad/go-grpcauth/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func testCELPolicy(t *testing.T, cfg grpcauth.CELPolicyConfig, rules map[string]string) *grpcauth.CELPolicy {
+	t.Helper()
+
+	p, err := grpcauth.NewCELPolicy(cfg)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	for pattern, expr := range rules {
+		if err := p.Set(pattern, expr); err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+	}
+
+	return p
+}
+
+func testTenantRequest(t *testing.T, tenant string) *structpb.Struct {
+	t.Helper()
+
+	req, err := structpb.NewStruct(map[string]any{"tenant_id": tenant})
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return req
+}
+
+func TestCELPolicy_Authorize(t *testing.T) {
+	evening := time.Date(2024, 6, 1, 19, 0, 0, 0, time.UTC)
+	p := testCELPolicy(t, grpcauth.CELPolicyConfig{Now: func() time.Time { return evening }}, map[string]string{
+		"/tenant.v1.Tenants/*":            `request.tenant_id == principal.claims.tenant`,
+		"/grpcauth.test.Test/TestOnline":  `principal.online || now.getHours("UTC") < 18`,
+		"/grpcauth.test.Test/TestOffline": `"admin" in principal.roles && method.endsWith("/TestOffline")`,
+		"/grpcauth.test.Other/Metadata":   `"acme" in metadata["x-tenant"] && peer.address.startsWith("10.")`,
+		grpcauth.HealthMethods:            `true`,
+	})
+
+	acme := &grpcauth.Principal{Subject: "acme-user", Online: true, Claims: map[string]any{"tenant": "acme"}}
+	offline := &grpcauth.Principal{Subject: "offline-user", Roles: []string{"admin"}}
+
+	tests := []struct {
+		name         string
+		method       string
+		principal    *grpcauth.Principal
+		md           metadata.MD
+		req          any
+		expectedCode codes.Code
+	}{
+		{"matching tenant", "/tenant.v1.Tenants/Get", acme, nil, testTenantRequest(t, "acme"), codes.OK},
+		{"other tenant", "/tenant.v1.Tenants/Get", acme, nil, testTenantRequest(t, "other"), codes.PermissionDenied},
+		{"missing claim", "/tenant.v1.Tenants/Get", offline, nil, testTenantRequest(t, "acme"), codes.PermissionDenied},
+		{"online after hours", "/grpcauth.test.Test/TestOnline", acme, nil, &test.EmptyRequest{}, codes.OK},
+		{"offline after hours", "/grpcauth.test.Test/TestOnline", offline, nil, &test.EmptyRequest{},
+			codes.PermissionDenied},
+		{"role", "/grpcauth.test.Test/TestOffline", offline, nil, &test.EmptyRequest{}, codes.OK},
+		{"missing role", "/grpcauth.test.Test/TestOffline", acme, nil, &test.EmptyRequest{}, codes.PermissionDenied},
+		{"metadata and peer", "/grpcauth.test.Other/Metadata", acme, metadata.Pairs("x-tenant", "acme"), nil,
+			codes.OK},
+		{"missing metadata", "/grpcauth.test.Other/Metadata", acme, nil, nil, codes.PermissionDenied},
+		{"public", "/grpc.health.v1.Health/Check", nil, nil, nil, codes.OK},
+		{"unauthenticated", "/grpcauth.test.Test/TestOffline", nil, nil, &test.EmptyRequest{}, codes.Unauthenticated},
+		{"unmapped", "/grpcauth.test.Other/Unmapped", acme, nil, nil, codes.OK},
+		{"unmapped unauthenticated", "/grpcauth.test.Other/Unmapped", nil, nil, nil, codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{
+				Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4242},
+			})
+
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			if tt.principal != nil {
+				ctx = grpcauth.NewContextWithPrincipal(ctx, tt.principal)
+			}
+
+			if err := p.Authorize(ctx, tt.method, tt.req); status.Code(err) != tt.expectedCode {
+				t.Errorf("expected status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestCELPolicy_DenyByDefault(t *testing.T) {
+	p := testCELPolicy(t, grpcauth.CELPolicyConfig{DenyByDefault: true}, nil)
+	ctx := grpcauth.NewContextWithPrincipal(context.Background(), &grpcauth.Principal{Subject: "user"})
+
+	if err := p.Authorize(ctx, "/grpcauth.test.Test/TestOnline", nil); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.PermissionDenied, status.Code(err))
+	}
+}
+
+func TestCELPolicy_Set_Invalid(t *testing.T) {
+	tests := []struct {
+		name, pattern, expr string
+	}{
+		{"syntax", "/grpcauth.test.Test/TestOnline", `principal.subject ==`},
+		{"not bool", "/grpcauth.test.Test/TestOnline", `principal.subject`},
+		{"undeclared variable", "/grpcauth.test.Test/TestOnline", `user == "admin"`},
+		{"unknown request field", "/grpcauth.test.Test/TestOnline", `request.tenant_id == "acme"`},
+		{"invalid pattern", "/grpcauth.test.Test/[", `true`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := grpcauth.NewCELPolicy(grpcauth.CELPolicyConfig{})
+			if err != nil {
+				t.Fatalf("expected error to be nil, returned '%v'", err)
+			}
+
+			if err := p.Set(tt.pattern, tt.expr); err == nil {
+				t.Error("expected error to be returned, but error returned nil")
+			}
+		})
+	}
+}
+
+func TestCELPolicy_Interceptors(t *testing.T) {
+	p := testCELPolicy(t, grpcauth.CELPolicyConfig{}, map[string]string{
+		"/tenant.v1.Tenants/Get":   `request.tenant_id == "acme"`,
+		"/tenant.v1.Tenants/List":  `principal.subject == "admin"`,
+		"/tenant.v1.Tenants/Watch": `request.tenant_id == principal.claims.tenant`,
+	})
+	ctx := grpcauth.NewContextWithPrincipal(context.Background(), &grpcauth.Principal{
+		Subject: "user",
+		Claims:  map[string]any{"tenant": "acme"},
+	})
+
+	_, err := p.UnaryServerInterceptor()(ctx, testTenantRequest(t, "acme"),
+		&grpc.UnaryServerInfo{FullMethod: "/tenant.v1.Tenants/Get"},
+		func(context.Context, any) (any, error) { return struct{}{}, nil })
+	if err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	// rules without the request are evaluated when the stream starts.
+	called := false
+
+	err = p.StreamServerInterceptor()(nil, &testAuthzServerStream{ctx: ctx},
+		&grpc.StreamServerInfo{FullMethod: "/tenant.v1.Tenants/List"},
+		func(any, grpc.ServerStream) error {
+			called = true
+
+			return nil
+		})
+	if status.Code(err) != codes.PermissionDenied || called {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.PermissionDenied, status.Code(err))
+	}
+
+	// rules with the request are evaluated against each message received.
+	tests := []struct {
+		name             string
+		tenants          []string
+		expectedCode     codes.Code
+		expectedReceived int
+	}{
+		{"all messages allowed", []string{"acme", "acme"}, codes.OK, 2},
+		{"message denied", []string{"acme", "other", "acme"}, codes.PermissionDenied, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := &testRecvServerStream{ctx: ctx}
+			for _, tenant := range tt.tenants {
+				ss.msgs = append(ss.msgs, testTenantRequest(t, tenant))
+			}
+
+			received := 0
+
+			err := p.StreamServerInterceptor()(nil, ss, &grpc.StreamServerInfo{FullMethod: "/tenant.v1.Tenants/Watch"},
+				func(_ any, ss grpc.ServerStream) error {
+					for {
+						if err := ss.RecvMsg(&structpb.Struct{}); err != nil {
+							if errors.Is(err, io.EOF) {
+								return nil
+							}
+
+							return err
+						}
+
+						received++
+					}
+				})
+			if status.Code(err) != tt.expectedCode {
+				t.Errorf("expected status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+
+			if received != tt.expectedReceived {
+				t.Errorf("expected '%d' messages to be received, received '%d'", tt.expectedReceived, received)
+			}
+		})
+	}
+}
+
+func TestCELPolicy_StreamSendOnly(t *testing.T) {
+	p := testCELPolicy(t, grpcauth.CELPolicyConfig{}, map[string]string{
+		"/tenant.v1.Tenants/Export": `principal.subject == "admin" && request.tenant_id == "acme"`,
+		"/tenant.v1.Tenants/Watch":  `request.tenant_id == principal.claims.tenant`,
+	})
+	user := &grpcauth.Principal{Subject: "user", Claims: map[string]any{"tenant": "acme"}}
+
+	// conditions that do not depend on the request are checked before a handler that never receives is called.
+	tests := []struct {
+		name         string
+		method       string
+		principal    *grpcauth.Principal
+		expectedCode codes.Code
+	}{
+		{"principal denied", "/tenant.v1.Tenants/Export", user, codes.PermissionDenied},
+		{"unauthenticated", "/tenant.v1.Tenants/Export", nil, codes.Unauthenticated},
+		{"request unknown", "/tenant.v1.Tenants/Watch", user, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = grpcauth.NewContextWithPrincipal(ctx, tt.principal)
+			}
+
+			called := false
+
+			err := p.StreamServerInterceptor()(nil, &testRecvServerStream{ctx: ctx},
+				&grpc.StreamServerInfo{FullMethod: tt.method},
+				func(any, grpc.ServerStream) error {
+					called = true
+
+					return nil
+				})
+			if status.Code(err) != tt.expectedCode || called != (tt.expectedCode == codes.OK) {
+				t.Errorf("expected status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+)
+
+// CertificateBoundConfig is the configuration for CertificateBoundFunc.
+type CertificateBoundConfig struct {
+	// Required rejects tokens that are not bound to a certificate, when false unbound tokens are accepted from any
+	// connection.
+	Required bool
+}
+
+// CertificateThumbprint returns the base64url encoded SHA-256 thumbprint of the certificate, as used by the
+// "x5t#S256" confirmation method (RFC 8705).
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// CertificateBoundFunc wraps the bearer verification function so certificate-bound access tokens (RFC 8705) are
+// only accepted over a connection using the certificate they were bound to.
+//
+// The "cnf" claim is read from the principal attached by the verification function (eg. JWTVerifier or
+// IntrospectionVerifier), tokens with an "x5t#S256" confirmation that does not match the thumbprint of the
+// verified client certificate are rejected as ErrInvalidCredentials.
+//
+// When combined with BearerCache or DeduplicateBearerFunc this must be the outermost wrapper, so the binding is
+// checked against the connection of every request.
+func CertificateBoundFunc(verify AuthVerifyBearerErrFunc, cfg CertificateBoundConfig) AuthVerifyBearerErrFunc {
+	return func(ctx context.Context, token string) (context.Context, string, bool, error) {
+		outCtx, user, online, err := verify(ctx, token)
+		if err != nil {
+			return outCtx, user, online, err
+		}
+
+		thumbprint, bound := principalConfirmation(outCtx, "x5t#S256")
+		if !bound {
+			if cfg.Required {
+				return ctx, "", false, fmt.Errorf("%w: token is not certificate bound", ErrInvalidCredentials)
+			}
+
+			return outCtx, user, online, nil
+		}
+
+		cert, ok := ClientCertificateFromContext(ctx)
+		if !ok {
+			return ctx, "", false, fmt.Errorf("%w: certificate bound token requires a client certificate",
+				ErrInvalidCredentials)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(CertificateThumbprint(cert)), []byte(thumbprint)) != 1 {
+			return ctx, "", false, fmt.Errorf("%w: client certificate does not match token binding",
+				ErrInvalidCredentials)
+		}
+
+		return outCtx, user, online, nil
+	}
+}
+
+// principalConfirmation returns the member of the principal "cnf" claim (eg. "x5t#S256") and if it was present, a
+// malformed member is returned as an empty string so it never matches.
+func principalConfirmation(ctx context.Context, name string) (string, bool) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	v, ok := p.Claim("cnf")
+	if !ok {
+		return "", false
+	}
+
+	cnf, ok := v.(map[string]any)
+	if !ok {
+		return "", false
+	}
+
+	member, ok := cnf[name]
+	value, _ := member.(string)
+
+	return value, ok
+}
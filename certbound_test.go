@@ -0,0 +1,90 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCertificateBoundFunc(t *testing.T) {
+	cert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "bound-client"}})
+	otherCert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other-client"}})
+
+	boundToken := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(
+		map[string]any{"cnf": map[string]any{"x5t#S256": grpcauth.CertificateThumbprint(cert)}},
+	))
+	malformedToken := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(
+		map[string]any{"cnf": map[string]any{"x5t#S256": 42}},
+	))
+	unboundToken := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(nil))
+
+	tests := []struct {
+		name        string
+		token       string
+		cert        *x509.Certificate
+		required    bool
+		expectedErr error
+	}{
+		{"bound with matching certificate", boundToken, cert, false, nil},
+		{"bound with other certificate", boundToken, otherCert, false, grpcauth.ErrInvalidCredentials},
+		{"bound without certificate", boundToken, nil, false, grpcauth.ErrInvalidCredentials},
+		{"malformed confirmation", malformedToken, cert, false, grpcauth.ErrInvalidCredentials},
+		{"unbound", unboundToken, nil, false, nil},
+		{"unbound when required", unboundToken, cert, true, grpcauth.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify := grpcauth.CertificateBoundFunc(
+				testJWTVerifier().VerifyBearer,
+				grpcauth.CertificateBoundConfig{Required: tt.required},
+			)
+
+			ctx := context.Background()
+			if tt.cert != nil {
+				ctx = peerContext(ctx, tt.cert, true)
+			}
+
+			_, user, _, err := verify(ctx, tt.token)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error to be '%v', returned '%v'", tt.expectedErr, err)
+			}
+
+			if tt.expectedErr == nil && user != "jwt-user" {
+				t.Errorf("expected user to be '%s', received '%s'", "jwt-user", user)
+			}
+		})
+	}
+}
+
+func TestCertificateBoundFunc_VerifyAuthorization(t *testing.T) {
+	cert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "bound-client"}})
+	otherCert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other-client"}})
+
+	token := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(
+		map[string]any{"cnf": map[string]any{"x5t#S256": grpcauth.CertificateThumbprint(cert)}},
+	))
+
+	authFunc := grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewBearerErrAuthenticator(
+		grpcauth.CertificateBoundFunc(testJWTVerifier().VerifyBearer, grpcauth.CertificateBoundConfig{}),
+	))
+
+	md := metadata.MD{"authorization": []string{"Bearer " + token}}
+
+	if _, err := authFunc(metadata.NewIncomingContext(peerContext(context.Background(), cert, true), md)); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	// a stolen token replayed over another connection is rejected.
+	_, err := authFunc(metadata.NewIncomingContext(peerContext(context.Background(), otherCert, true), md))
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, code)
+	}
+}
@@ -0,0 +1,116 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	channelBindingHeader = "x-channel-binding"
+
+	// tlsExporterLabel and tlsExporterLength are defined by RFC 9266.
+	tlsExporterLabel  = "EXPORTER-Channel-Binding"
+	tlsExporterLength = 32
+)
+
+// ErrChannelBindingUnavailable is returned when the connection does not support the tls-exporter channel binding,
+// it requires TLS 1.3 or TLS 1.2 with the extended master secret extension.
+var ErrChannelBindingUnavailable = errors.New("tls-exporter channel binding unavailable")
+
+// NewChannelBoundTokenCredentials returns a new PerRPCCredentials implementation, configured using the raw token,
+// that binds the token to the TLS connection.
+//
+// Each request includes an HMAC-SHA256, keyed with the token, over the RFC 9266 tls-exporter value of the
+// connection. The server checks it with ChannelBindingFunc, so a request relayed with its headers unchanged over a
+// different TLS connection is rejected.
+//
+// The binding is keyed with the token itself, anyone holding the token can compute a valid binding for their own
+// connection. It does not protect a stolen token, use DPoP or certificate-bound tokens for proof-of-possession.
+func NewChannelBoundTokenCredentials(token string) credentials.PerRPCCredentials {
+	return &TokenCreds{
+		token:          token,
+		channelBinding: true,
+	}
+}
+
+// ChannelBindingConfig is the configuration for ChannelBindingFunc.
+type ChannelBindingConfig struct {
+	// Required rejects requests without a channel binding, when false they are passed to the verification function
+	// unchanged.
+	Required bool
+}
+
+// ChannelBindingFunc wraps the bearer verification function so tokens sent with a channel binding (see
+// NewChannelBoundTokenCredentials) are only accepted over the TLS connection they were bound to.
+//
+// The binding is recomputed from the tls-exporter value of the connection, requests with a binding that does not
+// match are rejected as ErrInvalidCredentials without calling the verification function.
+//
+// As the binding is keyed with the token it only detects requests forwarded with their headers unchanged over a
+// different connection, a client holding the token can compute the binding for any connection.
+func ChannelBindingFunc(verify AuthVerifyBearerErrFunc, cfg ChannelBindingConfig) AuthVerifyBearerErrFunc {
+	return func(ctx context.Context, token string) (context.Context, string, bool, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		values := md.Get(channelBindingHeader)
+		switch {
+		case len(values) == 0 && cfg.Required:
+			return ctx, "", false, fmt.Errorf("%w: token is not channel bound", ErrInvalidCredentials)
+		case len(values) == 0:
+			return verify(ctx, token)
+		case len(values) > 1:
+			return ctx, "", false, fmt.Errorf("%w: multiple channel bindings", ErrInvalidCredentials)
+		}
+
+		mac, err := base64.RawURLEncoding.DecodeString(values[0])
+		if err != nil {
+			return ctx, "", false, fmt.Errorf("%w: malformed channel binding", ErrInvalidCredentials)
+		}
+
+		var ai credentials.AuthInfo
+		if p, ok := peer.FromContext(ctx); ok {
+			ai = p.AuthInfo
+		}
+
+		ekm, err := tlsExporter(ai)
+		if err != nil {
+			return ctx, "", false, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+		}
+
+		if !hmac.Equal(mac, channelBindingMAC(token, ekm)) {
+			return ctx, "", false, fmt.Errorf("%w: channel binding does not match connection", ErrInvalidCredentials)
+		}
+
+		return verify(ctx, token)
+	}
+}
+
+// tlsExporter returns the RFC 9266 tls-exporter channel binding of the connection.
+func tlsExporter(ai credentials.AuthInfo) ([]byte, error) {
+	tlsInfo, ok := ai.(credentials.TLSInfo)
+	if !ok || !tlsInfo.State.HandshakeComplete {
+		return nil, fmt.Errorf("%w: connection is not using TLS", ErrChannelBindingUnavailable)
+	}
+
+	ekm, err := tlsInfo.State.ExportKeyingMaterial(tlsExporterLabel, nil, tlsExporterLength)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrChannelBindingUnavailable, err)
+	}
+
+	return ekm, nil
+}
+
+func channelBindingMAC(token string, ekm []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write(ekm)
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,156 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// testTLSConnection performs a TLS handshake over an in-memory connection and returns the client and server
+// connection states.
+func testTLSConnection(t *testing.T) (tls.ConnectionState, tls.ConnectionState) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+
+	client := tls.Client(clientConn, &tls.Config{RootCAs: pool, ServerName: "localhost", MinVersion: tls.VersionTLS13})
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS13,
+	})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Handshake() }()
+
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return client.ConnectionState(), server.ConnectionState()
+}
+
+func testChannelBindingMetadata(t *testing.T, token string, state tls.ConnectionState) metadata.MD {
+	t.Helper()
+
+	ctx := credentials.NewContextWithRequestInfo(context.Background(), credentials.RequestInfo{
+		AuthInfo: credentials.TLSInfo{
+			State:          state,
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		},
+	})
+
+	r, err := grpcauth.NewChannelBoundTokenCredentials(token).GetRequestMetadata(ctx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return metadata.New(r)
+}
+
+func TestChannelBindingFunc(t *testing.T) {
+	token := string(validOnlineToken)
+	clientState, serverState := testTLSConnection(t)
+	_, otherServerState := testTLSConnection(t)
+
+	boundMD := testChannelBindingMetadata(t, token, clientState)
+	unboundMD := metadata.MD{"authorization": []string{"Bearer " + token}}
+
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		state       *tls.ConnectionState
+		required    bool
+		expectedErr error
+	}{
+		{"bound to connection", boundMD, &serverState, false, nil},
+		{"relayed to other connection", boundMD, &otherServerState, false, grpcauth.ErrInvalidCredentials},
+		{"bound without TLS", boundMD, nil, false, grpcauth.ErrChannelBindingUnavailable},
+		{"malformed binding", metadata.MD{"x-channel-binding": []string{"!"}}, &serverState, false,
+			grpcauth.ErrInvalidCredentials},
+		{"unbound", unboundMD, &serverState, false, nil},
+		{"unbound when required", unboundMD, &serverState, true, grpcauth.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify := grpcauth.ChannelBindingFunc(
+				grpcauth.BearerErrFunc(bearerAuthFunc),
+				grpcauth.ChannelBindingConfig{Required: tt.required},
+			)
+
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			if tt.state != nil {
+				ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: *tt.state}})
+			}
+
+			_, user, _, err := verify(ctx, token)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error to be '%v', returned '%v'", tt.expectedErr, err)
+			}
+
+			if tt.expectedErr == nil && user != "online-user" {
+				t.Errorf("expected user to be '%s', received '%s'", "online-user", user)
+			}
+		})
+	}
+}
+
+func TestChannelBoundTokenCredentials_Fail_NoTLS(t *testing.T) {
+	ctx := credentials.NewContextWithRequestInfo(context.Background(), credentials.RequestInfo{
+		AuthInfo: credentials.TLSInfo{
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		},
+	})
+
+	if _, err := grpcauth.NewChannelBoundTokenCredentials("token").GetRequestMetadata(ctx); !errors.Is(
+		err, grpcauth.ErrChannelBindingUnavailable,
+	) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrChannelBindingUnavailable, err)
+	}
+}
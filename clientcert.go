@@ -0,0 +1,213 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"regexp"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientCertScheme is the Principal scheme for client certificate authentication.
+const ClientCertScheme = "ClientCert"
+
+const clientCertPrincipalKey contextValue = "client-cert-principal"
+
+// ClientCertField is a certificate field that a ClientCertRule matches against.
+type ClientCertField int
+
+const (
+	// ClientCertCommonName matches the subject common name.
+	ClientCertCommonName ClientCertField = iota
+
+	// ClientCertDNSName matches the DNS subject alternative names.
+	ClientCertDNSName
+
+	// ClientCertURI matches the URI subject alternative names.
+	ClientCertURI
+
+	// ClientCertEmail matches the email subject alternative names.
+	ClientCertEmail
+)
+
+// ClientCertRule maps a client certificate to a username.
+type ClientCertRule struct {
+	// Field is the certificate field the rule matches, fields with more than one value match if any value matches.
+	Field ClientCertField
+
+	// Pattern is matched against the field value, a nil pattern matches any non-empty value.
+	Pattern *regexp.Regexp
+
+	// Username is the template for the username, "$1" or "${name}" are replaced by the pattern submatches. The
+	// field value is used when empty.
+	Username string
+}
+
+func (r ClientCertRule) values(cert *x509.Certificate) []string {
+	switch r.Field {
+	case ClientCertCommonName:
+		return []string{cert.Subject.CommonName}
+	case ClientCertDNSName:
+		return cert.DNSNames
+	case ClientCertURI:
+		values := make([]string, 0, len(cert.URIs))
+		for _, u := range cert.URIs {
+			values = append(values, u.String())
+		}
+
+		return values
+	case ClientCertEmail:
+		return cert.EmailAddresses
+	}
+
+	return nil
+}
+
+func (r ClientCertRule) username(cert *x509.Certificate) (string, bool) {
+	for _, v := range r.values(cert) {
+		if v == "" {
+			continue
+		}
+
+		if r.Pattern == nil {
+			if r.Username != "" {
+				return r.Username, true
+			}
+
+			return v, true
+		}
+
+		match := r.Pattern.FindStringSubmatchIndex(v)
+		if match == nil {
+			continue
+		}
+
+		if r.Username == "" {
+			return v, true
+		}
+
+		return string(r.Pattern.ExpandString(nil, r.Username, v, match)), true
+	}
+
+	return "", false
+}
+
+// ClientCertConfig is the configuration for NewClientCertAuthenticator.
+type ClientCertConfig struct {
+	// Rules map the client certificate to a username, the first matching rule is used. Certificates that do not
+	// match any rule are rejected.
+	Rules []ClientCertRule
+}
+
+// ClientCertAuthenticator authenticates requests using the verified client certificate from the TLS connection.
+//
+// The server must be configured to verify client certificates (tls.RequireAndVerifyClientCert or
+// tls.VerifyClientCertIfGiven), certificates that were not verified during the handshake are ignored. Principals
+// are offline and expire when the certificate does.
+type ClientCertAuthenticator struct {
+	scheme  string
+	mapCert func(*x509.Certificate) (*Principal, bool)
+}
+
+// NewClientCertAuthenticator returns a new ClientCertAuthenticator.
+func NewClientCertAuthenticator(cfg ClientCertConfig) *ClientCertAuthenticator {
+	return &ClientCertAuthenticator{
+		scheme: ClientCertScheme,
+		mapCert: func(cert *x509.Certificate) (*Principal, bool) {
+			for _, rule := range cfg.Rules {
+				if u, ok := rule.username(cert); ok {
+					return &Principal{Subject: u}, true
+				}
+			}
+
+			return nil, false
+		},
+	}
+}
+
+// Verify authenticates the request using only the client certificate, it can be used as the gRPC AuthFunc.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func (a *ClientCertAuthenticator) Verify(ctx context.Context) (context.Context, error) {
+	cert, ok := ClientCertificateFromContext(ctx)
+	if !ok {
+		return ctx, credentialsMissing("client certificate required")
+	}
+
+	p, ok := a.mapCert(cert)
+	if !ok {
+		return ctx, verifierError(a.scheme, ErrInvalidCredentials)
+	}
+
+	p.Scheme = a.scheme
+	p.AuthenticatedAt = time.Now()
+	p.ExpiresAt = cert.NotAfter
+
+	if p.Claims == nil {
+		p.Claims = map[string]any{}
+	}
+
+	p.Claims["cert_subject"] = cert.Subject.String()
+	p.Claims["cert_serial"] = cert.SerialNumber.String()
+
+	return NewContextWithPrincipal(context.WithValue(ctx, clientCertPrincipalKey, p), p), nil
+}
+
+// Or returns a function that uses authFunc when the request has credentials it verifies and the client
+// certificate when authFunc returns ErrCredentialsMissing.
+func (a *ClientCertAuthenticator) Or(
+	authFunc func(ctx context.Context) (context.Context, error),
+) func(ctx context.Context) (context.Context, error) {
+	return func(ctx context.Context) (context.Context, error) {
+		outCtx, err := authFunc(ctx)
+		if errors.Is(err, ErrCredentialsMissing) {
+			return a.Verify(ctx)
+		}
+
+		return outCtx, err
+	}
+}
+
+// And returns a function that requires both the client certificate and authFunc to succeed.
+//
+// The principal is the one authenticated by authFunc, the client certificate principal is available from
+// ClientCertPrincipalFromContext.
+func (a *ClientCertAuthenticator) And(
+	authFunc func(ctx context.Context) (context.Context, error),
+) func(ctx context.Context) (context.Context, error) {
+	return func(ctx context.Context) (context.Context, error) {
+		certCtx, err := a.Verify(ctx)
+		if err != nil {
+			return ctx, err
+		}
+
+		return authFunc(certCtx)
+	}
+}
+
+// ClientCertPrincipalFromContext returns the principal authenticated by a ClientCertAuthenticator and if it was
+// present.
+func ClientCertPrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(clientCertPrincipalKey).(*Principal)
+
+	return p, ok && p != nil
+}
+
+// ClientCertificateFromContext returns the client certificate that was verified during the TLS handshake and if
+// it was present.
+func ClientCertificateFromContext(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0], true
+}
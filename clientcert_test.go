@@ -0,0 +1,266 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func testClientCert(t *testing.T, tmpl *x509.Certificate) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	tmpl.SerialNumber = big.NewInt(42)
+	tmpl.NotBefore = testJWTNow.Add(-time.Hour)
+	tmpl.NotAfter = testJWTNow.Add(24 * time.Hour)
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return cert
+}
+
+func peerContext(ctx context.Context, cert *x509.Certificate, verified bool) context.Context {
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if verified {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+func testClientCertAuthenticator() *grpcauth.ClientCertAuthenticator {
+	return grpcauth.NewClientCertAuthenticator(grpcauth.ClientCertConfig{
+		Rules: []grpcauth.ClientCertRule{
+			{
+				Field:    grpcauth.ClientCertURI,
+				Pattern:  regexp.MustCompile(`^https://services\.example\.com/(\w+)$`),
+				Username: "svc-$1",
+			},
+			{
+				Field:   grpcauth.ClientCertDNSName,
+				Pattern: regexp.MustCompile(`\.internal\.example\.com$`),
+			},
+			{
+				Field:    grpcauth.ClientCertEmail,
+				Pattern:  regexp.MustCompile(`^(?P<user>[^@]+)@example\.com$`),
+				Username: "${user}",
+			},
+			{
+				Field:   grpcauth.ClientCertCommonName,
+				Pattern: regexp.MustCompile(`^legacy-`),
+			},
+		},
+	})
+}
+
+func TestClientCertAuthenticator_Verify(t *testing.T) {
+	ordersURI, _ := url.Parse("https://services.example.com/orders")
+
+	tests := []struct {
+		name         string
+		tmpl         *x509.Certificate
+		verified     bool
+		expectedUser string
+		expectedCode codes.Code
+	}{
+		{"uri rule", &x509.Certificate{URIs: []*url.URL{ordersURI}}, true, "svc-orders", codes.OK},
+		{
+			"dns rule",
+			&x509.Certificate{DNSNames: []string{"www.example.com", "billing.internal.example.com"}},
+			true,
+			"billing.internal.example.com",
+			codes.OK,
+		},
+		{"email rule", &x509.Certificate{EmailAddresses: []string{"alice@example.com"}}, true, "alice", codes.OK},
+		{
+			"common name rule",
+			&x509.Certificate{Subject: pkix.Name{CommonName: "legacy-batch"}},
+			true,
+			"legacy-batch",
+			codes.OK,
+		},
+		{
+			"no matching rule",
+			&x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}},
+			true,
+			"",
+			codes.Unauthenticated,
+		},
+		{
+			"not verified",
+			&x509.Certificate{Subject: pkix.Name{CommonName: "legacy-batch"}},
+			false,
+			"",
+			codes.Unauthenticated,
+		},
+	}
+
+	a := testClientCertAuthenticator()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peerContext(context.Background(), testClientCert(t, tt.tmpl), tt.verified)
+
+			authCtx, err := a.Verify(ctx)
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Fatalf("expected status code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+
+			if tt.expectedCode != codes.OK {
+				return
+			}
+
+			p, ok := grpcauth.PrincipalFromContext(authCtx)
+			if !ok || p.Subject != tt.expectedUser || p.Scheme != grpcauth.ClientCertScheme || p.Online {
+				t.Errorf("expected offline principal '%s', received '%+v'", tt.expectedUser, p)
+			}
+
+			if !p.ExpiresAt.Equal(testJWTNow.Add(24 * time.Hour)) {
+				t.Errorf("expected principal to expire with the certificate, received '%s'", p.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestClientCertAuthenticator_NoPeer(t *testing.T) {
+	if _, err := testClientCertAuthenticator().Verify(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestClientCertAuthenticator_Or(t *testing.T) {
+	cert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "legacy-batch"}})
+	authFunc := testClientCertAuthenticator().Or(
+		grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewBearerErrAuthenticator((&countingBearerVerifier{}).VerifyBearer)),
+	)
+
+	// certificate is used without an authorization header.
+	authCtx, err := authFunc(peerContext(context.Background(), cert, true))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "legacy-batch" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "legacy-batch", v)
+	}
+
+	// authorization header is preferred over the certificate.
+	ctx := metadata.NewIncomingContext(peerContext(context.Background(), cert, true), metadata.MD{
+		"authorization": []string{"Bearer valid-token"},
+	})
+
+	if authCtx, err = authFunc(ctx); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "cached-valid-token" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "cached-valid-token", v)
+	}
+}
+
+func TestClientCertAuthenticator_Or_Header(t *testing.T) {
+	cert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "legacy-batch"}})
+	header := grpcauth.NewHeaderAuthenticator(grpcauth.HeaderConfig{
+		Header: "x-api-key",
+		Verify: (&countingBearerVerifier{}).VerifyBearer,
+	})
+	authFunc := testClientCertAuthenticator().Or(header.Or(
+		grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewBearerErrAuthenticator((&countingBearerVerifier{}).VerifyBearer)),
+	))
+
+	// custom header is used without an authorization header.
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "valid-token"))
+
+	authCtx, err := authFunc(ctx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "cached-valid-token" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "cached-valid-token", v)
+	}
+
+	// certificate is used without any other credentials, including unknown authorization schemes.
+	ctx = metadata.NewIncomingContext(peerContext(context.Background(), cert, true), metadata.Pairs(
+		"authorization", "Unknown abc",
+	))
+
+	if authCtx, err = authFunc(ctx); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "legacy-batch" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "legacy-batch", v)
+	}
+
+	// invalid credentials do not fall back to the certificate.
+	ctx = metadata.NewIncomingContext(peerContext(context.Background(), cert, true), metadata.Pairs(
+		"x-api-key", "invalid-token",
+	))
+
+	if _, err := authFunc(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestClientCertAuthenticator_And(t *testing.T) {
+	cert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "legacy-batch"}})
+	authFunc := testClientCertAuthenticator().And(
+		grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewBearerErrAuthenticator((&countingBearerVerifier{}).VerifyBearer)),
+	)
+
+	md := metadata.MD{"authorization": []string{"Bearer valid-token"}}
+
+	authCtx, err := authFunc(metadata.NewIncomingContext(peerContext(context.Background(), cert, true), md))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if p, ok := grpcauth.PrincipalFromContext(authCtx); !ok || p.Subject != "cached-valid-token" || p.Scheme != "Bearer" {
+		t.Errorf("expected principal to be the bearer user, received '%+v'", p)
+	}
+
+	if p, ok := grpcauth.ClientCertPrincipalFromContext(authCtx); !ok || p.Subject != "legacy-batch" {
+		t.Errorf("expected client certificate principal to be 'legacy-batch', received '%+v'", p)
+	}
+
+	// header alone is not enough.
+	if _, err := authFunc(metadata.NewIncomingContext(context.Background(), md)); status.Code(
+		err,
+	) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+
+	// certificate alone is not enough.
+	if _, err := authFunc(peerContext(context.Background(), cert, true)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
@@ -0,0 +1,280 @@
+package grpcauth
+
+import (
+	"crypto/md5" //nolint:gosec // required by the apr1 htpasswd format.
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+const (
+	cryptAlphabet       = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	apr1Magic           = "$apr1$"
+	apr1MaxSalt         = 8
+	apr1Rounds          = 1000
+	shaCryptMaxSalt     = 16
+	shaCryptRounds      = 5000
+	shaCryptMinRounds   = 1000
+	shaCryptMaxRounds   = 999999999
+	shaCryptRoundsLabel = "rounds="
+)
+
+var errCryptMalformed = errors.New("malformed crypt hash")
+
+//nolint:gochecknoglobals // byte transposition tables from the SHA-crypt specification.
+var (
+	sha256CryptOrder = [][3]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29},
+	}
+	sha512CryptOrder = [][3]int{
+		{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+		{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+		{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+		{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+		{62, 20, 41},
+	}
+)
+
+// cryptB64 appends n characters encoding the 24 bit value in the crypt base64 alphabet.
+func cryptB64(out []byte, b2, b1, b0 byte, n int) []byte {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for range n {
+		out = append(out, cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+
+	return out
+}
+
+// verifyCrypt compares the password against an apr1-MD5 ("$apr1$"), SHA-256 ("$5$") or SHA-512 ("$6$") crypt hash
+// in constant time.
+func verifyCrypt(hashed, password string) (bool, error) {
+	var (
+		computed string
+		err      error
+	)
+
+	switch {
+	case strings.HasPrefix(hashed, apr1Magic):
+		computed, err = apr1Crypt(password, hashed)
+	case strings.HasPrefix(hashed, "$5$"):
+		computed, err = shaCrypt(sha256.New, "$5$", sha256CryptOrder, password, hashed)
+	case strings.HasPrefix(hashed, "$6$"):
+		computed, err = shaCrypt(sha512.New, "$6$", sha512CryptOrder, password, hashed)
+	default:
+		return false, errCryptMalformed
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(hashed)) == 1, nil
+}
+
+// apr1Crypt returns the apr1-MD5 crypt of the password using the salt from the settings.
+//
+//nolint:mnd,gosec // constants are defined by the algorithm.
+func apr1Crypt(password, settings string) (string, error) {
+	salt, _, ok := strings.Cut(strings.TrimPrefix(settings, apr1Magic), "$")
+	if !ok && salt == "" {
+		return "", errCryptMalformed
+	}
+
+	if len(salt) > apr1MaxSalt {
+		salt = salt[:apr1MaxSalt]
+	}
+
+	pw := []byte(password)
+
+	alt := md5.New()
+	alt.Write(pw)
+	alt.Write([]byte(salt))
+	alt.Write(pw)
+	final := alt.Sum(nil)
+
+	h := md5.New()
+	h.Write(pw)
+	h.Write([]byte(apr1Magic))
+	h.Write([]byte(salt))
+
+	for pl := len(pw); pl > 0; pl -= 16 {
+		h.Write(final[:min(pl, 16)])
+	}
+
+	for i := len(pw); i != 0; i >>= 1 {
+		if i&1 == 1 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(pw[:1])
+		}
+	}
+
+	final = h.Sum(nil)
+
+	for i := range apr1Rounds {
+		r := md5.New()
+
+		if i&1 == 1 {
+			r.Write(pw)
+		} else {
+			r.Write(final)
+		}
+
+		if i%3 != 0 {
+			r.Write([]byte(salt))
+		}
+
+		if i%7 != 0 {
+			r.Write(pw)
+		}
+
+		if i&1 == 1 {
+			r.Write(final)
+		} else {
+			r.Write(pw)
+		}
+
+		final = r.Sum(nil)
+	}
+
+	out := []byte(apr1Magic + salt + "$")
+	for _, g := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		out = cryptB64(out, final[g[0]], final[g[1]], final[g[2]], 4)
+	}
+
+	out = cryptB64(out, 0, 0, final[11], 2)
+
+	return string(out), nil
+}
+
+// shaCrypt returns the SHA-crypt of the password using the salt and rounds from the settings.
+//
+//nolint:mnd // constants are defined by the algorithm.
+func shaCrypt(newHash func() hash.Hash, magic string, order [][3]int, password, settings string) (string, error) {
+	rest := strings.TrimPrefix(settings, magic)
+	rounds := shaCryptRounds
+	customRounds := false
+
+	if strings.HasPrefix(rest, shaCryptRoundsLabel) {
+		r, after, ok := strings.Cut(strings.TrimPrefix(rest, shaCryptRoundsLabel), "$")
+		if !ok {
+			return "", errCryptMalformed
+		}
+
+		n, err := strconv.Atoi(r)
+		if err != nil {
+			return "", errCryptMalformed
+		}
+
+		rounds = max(shaCryptMinRounds, min(n, shaCryptMaxRounds))
+		customRounds = true
+		rest = after
+	}
+
+	salt, _, _ := strings.Cut(rest, "$")
+	if len(salt) > shaCryptMaxSalt {
+		salt = salt[:shaCryptMaxSalt]
+	}
+
+	pw, s := []byte(password), []byte(salt)
+
+	b := newHash()
+	b.Write(pw)
+	b.Write(s)
+	b.Write(pw)
+	sumB := b.Sum(nil)
+	size := len(sumB)
+
+	a := newHash()
+	a.Write(pw)
+	a.Write(s)
+
+	for pl := len(pw); pl > 0; pl -= size {
+		a.Write(sumB[:min(pl, size)])
+	}
+
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			a.Write(sumB)
+		} else {
+			a.Write(pw)
+		}
+	}
+
+	sumA := a.Sum(nil)
+
+	dp := newHash()
+	for range len(pw) {
+		dp.Write(pw)
+	}
+
+	pSeq := repeatBytes(dp.Sum(nil), len(pw))
+
+	ds := newHash()
+	for range 16 + int(sumA[0]) {
+		ds.Write(s)
+	}
+
+	sSeq := repeatBytes(ds.Sum(nil), len(s))
+
+	c := sumA
+	for i := range rounds {
+		r := newHash()
+
+		if i&1 == 1 {
+			r.Write(pSeq)
+		} else {
+			r.Write(c)
+		}
+
+		if i%3 != 0 {
+			r.Write(sSeq)
+		}
+
+		if i%7 != 0 {
+			r.Write(pSeq)
+		}
+
+		if i&1 == 1 {
+			r.Write(c)
+		} else {
+			r.Write(pSeq)
+		}
+
+		c = r.Sum(nil)
+	}
+
+	out := []byte(magic)
+	if customRounds {
+		out = append(out, shaCryptRoundsLabel+strconv.Itoa(rounds)+"$"...)
+	}
+
+	out = append(out, salt+"$"...)
+
+	for _, g := range order {
+		out = cryptB64(out, c[g[0]], c[g[1]], c[g[2]], 4)
+	}
+
+	if size == sha256.Size {
+		out = cryptB64(out, 0, c[31], c[30], 3)
+	} else {
+		out = cryptB64(out, 0, 0, c[63], 2)
+	}
+
+	return string(out), nil
+}
+
+func repeatBytes(b []byte, n int) []byte {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		out = append(out, b[:min(len(b), n-len(out))]...)
+	}
+
+	return out
+}
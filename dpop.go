@@ -0,0 +1,397 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// DPoPScheme is the authorization scheme for DPoP-bound access tokens (RFC 9449).
+const DPoPScheme = "DPoP"
+
+const (
+	dpopHeader        = "dpop"
+	dpopProofType     = "dpop+jwt"
+	defaultDPoPWindow = time.Minute
+	dpopJTILength     = 16
+)
+
+// NewDPoPCredentials returns a new PerRPCCredentials implementation that sends the DPoP-bound access token with a
+// proof signed by the key for every request.
+//
+// The key must be an *ecdsa.PrivateKey (P-256, P-384 or P-521), ed25519.PrivateKey, *rsa.PrivateKey, or a
+// crypto.Signer with one of their public keys.
+func NewDPoPCredentials(token string, key crypto.Signer) (*DPoPCreds, error) {
+	alg, err := jwtSigningAlgorithm(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := newJSONWebKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &DPoPCreds{
+		token: token,
+		key:   key,
+		alg:   alg,
+		jwk:   jwk,
+	}, nil
+}
+
+// DPoPCreds is the PerRPCCredentials implementation for DPoP-bound access tokens.
+type DPoPCreds struct {
+	token string
+	key   crypto.Signer
+	alg   string
+	jwk   jsonWebKey
+}
+
+// GetRequestMetadata adds the HTTP Authorization DPoP header and a DPoP proof to the request.
+//
+// The proof "htm" claim is the full gRPC method and "htu" is the URI of the service being called.
+func (c *DPoPCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	ri, _ := credentials.RequestInfoFromContext(ctx)
+	if err := credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity); err != nil {
+		return nil, fmt.Errorf("unable to transfer DPoP PerRPCCredentials: %w", err)
+	}
+
+	if len(uri) == 0 {
+		return nil, fmt.Errorf("unable to create DPoP proof: request URI missing")
+	}
+
+	jti := make([]byte, dpopJTILength)
+	if _, err := rand.Read(jti); err != nil {
+		return nil, fmt.Errorf("unable to create DPoP proof: %w", err)
+	}
+
+	proof, err := signJWT(c.key, c.alg, map[string]any{
+		"typ": dpopProofType,
+		"jwk": c.jwk,
+	}, map[string]any{
+		"jti": base64.RawURLEncoding.EncodeToString(jti),
+		"htm": ri.Method,
+		"htu": uri[0],
+		"iat": time.Now().Unix(),
+		"ath": dpopAccessTokenHash(c.token),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create DPoP proof: %w", err)
+	}
+
+	return map[string]string{
+		"Authorization": DPoPScheme + " " + c.token,
+		dpopHeader:      proof,
+	}, nil
+}
+
+// RequireTransportSecurity indicates whether the credentials requires
+// transport security.
+func (c *DPoPCreds) RequireTransportSecurity() bool {
+	return true
+}
+
+// DPoPConfig is the configuration for NewDPoPAuthenticator.
+type DPoPConfig struct {
+	// Verify verifies the access token, the principal it attaches must have a "cnf" claim with a "jkt" member
+	// matching the proof key (eg. JWTVerifier.VerifyBearer or IntrospectionVerifier.VerifyBearer).
+	Verify AuthVerifyBearerErrFunc
+
+	// Algorithms restricts the proof signature algorithms accepted, all supported asymmetric algorithms are
+	// accepted when empty.
+	Algorithms []string
+
+	// Window is how far the proof "iat" may be from the current time, proof "jti" values are remembered for twice
+	// the window to reject replays, defaults to 1 minute.
+	Window time.Duration
+
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewDPoPAuthenticator returns an Authenticator for the DPoP authorization scheme.
+func NewDPoPAuthenticator(cfg DPoPConfig) *DPoPAuthenticator {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultDPoPWindow
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &DPoPAuthenticator{
+		cfg:    cfg,
+		replay: newReplayCache(2 * cfg.Window), //nolint:mnd // iat may be up to a window either side of now.
+	}
+}
+
+// DPoPAuthenticator verifies DPoP proofs (RFC 9449) and the access tokens bound to them.
+//
+// The proof must be for the method being called, its "ath" claim must match the access token and its "jti" must
+// not have been seen within the window. Replays are only detected by the same DPoPAuthenticator.
+type DPoPAuthenticator struct {
+	cfg    DPoPConfig
+	replay *replayCache
+}
+
+// Scheme returns "DPoP".
+func (a *DPoPAuthenticator) Scheme() string {
+	return DPoPScheme
+}
+
+// Authenticate verifies the DPoP proof from the request metadata and the access token.
+func (a *DPoPAuthenticator) Authenticate(ctx context.Context, token string) (context.Context, error) {
+	jkt, err := a.verifyProof(ctx, token)
+	if err != nil {
+		return ctx, verifierError(DPoPScheme, err)
+	}
+
+	outCtx, u, online, err := a.cfg.Verify(ctx, token)
+	if err != nil {
+		return ctx, verifierError(DPoPScheme, err)
+	}
+
+	if bound, ok := principalConfirmation(outCtx, "jkt"); !ok || bound != jkt {
+		return ctx, verifierError(DPoPScheme, fmt.Errorf("%w: token is not bound to the proof key",
+			ErrInvalidCredentials))
+	}
+
+	return newContextWithAuthenticated(ctx, outCtx, DPoPScheme, u, online), nil
+}
+
+// verifyProof verifies the DPoP proof for the access token and returns the JWK thumbprint of the proof key.
+//
+//nolint:cyclop // each proof requirement is checked in turn.
+func (a *DPoPAuthenticator) verifyProof(ctx context.Context, token string) (string, error) {
+	var proofs []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		proofs = md.Get(dpopHeader)
+	}
+
+	if len(proofs) != 1 {
+		return "", fmt.Errorf("%w: exactly one DPoP proof is required", ErrInvalidCredentials)
+	}
+
+	header, claims, signed, sig, err := parseJWT(proofs[0])
+	if err != nil {
+		return "", err
+	}
+
+	alg, _ := header["alg"].(string)
+	if typ, _ := header["typ"].(string); typ != dpopProofType || !a.acceptedAlgorithm(alg) {
+		return "", fmt.Errorf("%w: DPoP proof type or algorithm not accepted", ErrInvalidCredentials)
+	}
+
+	key, err := dpopProofKey(header["jwk"])
+	if err != nil {
+		return "", err
+	}
+
+	if err := verifyJWTSignature(alg, key, signed, sig); err != nil {
+		return "", err
+	}
+
+	method, _ := grpc.Method(ctx)
+	if htm, _ := claims["htm"].(string); htm == "" || htm != method {
+		return "", fmt.Errorf("%w: DPoP proof htm does not match the method", ErrInvalidCredentials)
+	}
+
+	if htu, _ := claims["htu"].(string); !dpopHTUMatches(ctx, htu, method) {
+		return "", fmt.Errorf("%w: DPoP proof htu does not match the service", ErrInvalidCredentials)
+	}
+
+	if ath, _ := claims["ath"].(string); ath != dpopAccessTokenHash(token) {
+		return "", fmt.Errorf("%w: DPoP proof ath does not match the token", ErrInvalidCredentials)
+	}
+
+	iat, ok := claimTime(claims["iat"])
+	if now := a.cfg.Now(); !ok || iat.Before(now.Add(-a.cfg.Window)) || iat.After(now.Add(a.cfg.Window)) {
+		return "", fmt.Errorf("%w: DPoP proof iat outside of the window", ErrInvalidCredentials)
+	}
+
+	jkt, err := JWKThumbprint(key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" || !a.replay.remember(a.cfg.Now(), jkt, jti) {
+		return "", fmt.Errorf("%w: DPoP proof jti missing or replayed", ErrInvalidCredentials)
+	}
+
+	return jkt, nil
+}
+
+func (a *DPoPAuthenticator) acceptedAlgorithm(alg string) bool {
+	if alg == "" || strings.HasPrefix(alg, "HS") || strings.EqualFold(alg, "none") {
+		return false
+	}
+
+	return len(a.cfg.Algorithms) == 0 || slices.Contains(a.cfg.Algorithms, alg)
+}
+
+// dpopProofKey returns the public key from the proof "jwk" header, private and symmetric keys are rejected.
+func dpopProofKey(v any) (any, error) {
+	members, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: DPoP proof jwk missing", ErrInvalidCredentials)
+	}
+
+	if _, private := members["d"]; private {
+		return nil, fmt.Errorf("%w: DPoP proof jwk must be a public key", ErrInvalidCredentials)
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return nil, fmt.Errorf("%w: DPoP proof jwk malformed", ErrInvalidCredentials)
+	}
+
+	var jwk jsonWebKey
+	if err := json.Unmarshal(b, &jwk); err != nil || jwk.Kty == "oct" {
+		return nil, fmt.Errorf("%w: DPoP proof jwk malformed", ErrInvalidCredentials)
+	}
+
+	key, err := jwk.publicKey()
+	if err != nil || key == nil {
+		return nil, fmt.Errorf("%w: DPoP proof jwk not supported", ErrInvalidCredentials)
+	}
+
+	return key, nil
+}
+
+// dpopHTUMatches returns true if the htu is the URI of the service for the method, the host is checked against
+// the ":authority" of the request when it is available.
+func dpopHTUMatches(ctx context.Context, htu, method string) bool {
+	i := strings.LastIndex(method, "/")
+	if i <= 0 {
+		return false
+	}
+
+	u, err := url.Parse(htu)
+	if err != nil || u.Path != method[:i] {
+		return false
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if authority := md.Get(":authority"); len(authority) > 0 {
+			return u.Host == authority[0] || u.Host == strings.TrimSuffix(authority[0], ":443")
+		}
+	}
+
+	return true
+}
+
+func dpopAccessTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// jwtSigningAlgorithm returns the JWT algorithm used to sign with the key.
+func jwtSigningAlgorithm(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().Name {
+		case "P-256":
+			return JWTAlgES256, nil
+		case "P-384":
+			return JWTAlgES384, nil
+		case "P-521":
+			return JWTAlgES512, nil
+		}
+	case *rsa.PublicKey:
+		return JWTAlgRS256, nil
+	case ed25519.PublicKey:
+		return JWTAlgEdDSA, nil
+	}
+
+	return "", fmt.Errorf("unsupported signing key type %T", key)
+}
+
+// signJWT returns the JWT signed with the key.
+func signJWT(key crypto.Signer, alg string, header, claims map[string]any) (string, error) {
+	header["alg"] = alg
+
+	hb, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode jwt header: %w", err)
+	}
+
+	cb, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode jwt claims: %w", err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(cb)
+
+	var sig []byte
+
+	if alg == JWTAlgEdDSA {
+		sig, err = key.Sign(rand.Reader, []byte(signed), crypto.Hash(0))
+	} else {
+		sig, err = signJWTDigest(key, alg, signed)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("unable to sign jwt: %w", err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func signJWTDigest(key crypto.Signer, alg, signed string) ([]byte, error) {
+	hash, _ := jwtHash(alg)
+
+	var digest []byte
+
+	switch hash {
+	case crypto.SHA384:
+		s := sha512.Sum384([]byte(signed))
+		digest = s[:]
+	case crypto.SHA512:
+		s := sha512.Sum512([]byte(signed))
+		digest = s[:]
+	default:
+		s := sha256.Sum256([]byte(signed))
+		digest = s[:]
+	}
+
+	sig, err := key.Sign(rand.Reader, digest, hash)
+	if err != nil || !strings.HasPrefix(alg, "ES") {
+		return sig, err //nolint:wrapcheck // wrapped by signJWT.
+	}
+
+	// ecdsa signers return ASN.1 signatures, JWTs use the fixed size r || s.
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &rs); err != nil {
+		return nil, fmt.Errorf("invalid ecdsa signature: %w", err)
+	}
+
+	pub, _ := key.Public().(*ecdsa.PublicKey)
+	size := (pub.Curve.Params().BitSize + 7) / 8 //nolint:mnd // bits to bytes.
+
+	out := make([]byte, 2*size) //nolint:mnd // signature is r || s.
+	rs.R.FillBytes(out[:size])
+	rs.S.FillBytes(out[size:])
+
+	return out, nil
+}
@@ -0,0 +1,236 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testDPoPMethod = "/grpcauth.test.Test/TestOnline"
+	testDPoPURI    = "https://localhost:8000/grpcauth.test.Test"
+)
+
+func testDPoPToken(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+
+	jkt, err := grpcauth.JWKThumbprint(key)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(
+		map[string]any{"cnf": map[string]any{"jkt": jkt}},
+	))
+}
+
+func testDPoPMetadata(t *testing.T, token string, key crypto.Signer, method, uri string) metadata.MD {
+	t.Helper()
+
+	creds, err := grpcauth.NewDPoPCredentials(token, key)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	ctx := credentials.NewContextWithRequestInfo(context.Background(), credentials.RequestInfo{
+		Method: method,
+		AuthInfo: credentials.TLSInfo{
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		},
+	})
+
+	r, err := creds.GetRequestMetadata(ctx, uri)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	md := metadata.New(r)
+	md.Set(":authority", "localhost:8000")
+
+	return md
+}
+
+func testDPoPAuthFunc(now time.Time) func(context.Context) (context.Context, error) {
+	return grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewDPoPAuthenticator(grpcauth.DPoPConfig{
+		Verify: testJWTVerifier().VerifyBearer,
+		Now:    func() time.Time { return now },
+	}))
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// example from RFC 7638 section 3.1.
+	jwks, err := grpcauth.NewJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"2011-04-29","e":"AQAB","n":"` +
+		`0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknj` +
+		`hMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qM` +
+		`QvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJ` +
+		`zKnqDKgw"}]}`))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	key, err := jwks.Key(context.Background(), "2011-04-29", grpcauth.JWTAlgRS256)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	expected := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+	if v, err := grpcauth.JWKThumbprint(key); err != nil || v != expected {
+		t.Errorf("expected thumbprint to be '%s', received '%s' '%v'", expected, v, err)
+	}
+}
+
+func TestDPoP_Keys(t *testing.T) {
+	tests := []struct {
+		name string
+		key  crypto.Signer
+	}{
+		{"ES256", testEC256Key},
+		{"ES384", testEC384Key},
+		{"ES512", testEC521Key},
+		{"EdDSA", testEdKey},
+		{"RS256", testRSAKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := testDPoPToken(t, tt.key.Public())
+			md := testDPoPMetadata(t, token, tt.key, testDPoPMethod, testDPoPURI)
+
+			authCtx, err := testDPoPAuthFunc(time.Now())(incomingContext(testDPoPMethod, md))
+			if err != nil {
+				t.Fatalf("expected error to be nil, returned '%v'", err)
+			}
+
+			if p, ok := grpcauth.PrincipalFromContext(authCtx); !ok || p.Subject != "jwt-user" ||
+				p.Scheme != grpcauth.DPoPScheme {
+				t.Errorf("expected principal 'jwt-user' with scheme '%s', received '%+v'", grpcauth.DPoPScheme, p)
+			}
+		})
+	}
+}
+
+func TestDPoP_Replay(t *testing.T) {
+	token := testDPoPToken(t, testEC256Key.Public())
+	md := testDPoPMetadata(t, token, testEC256Key, testDPoPMethod, testDPoPURI)
+	authFunc := testDPoPAuthFunc(time.Now())
+
+	if _, err := authFunc(incomingContext(testDPoPMethod, md)); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if _, err := authFunc(incomingContext(testDPoPMethod, md)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestDPoP_Invalid(t *testing.T) {
+	token := testDPoPToken(t, testEC256Key.Public())
+	otherToken := testDPoPToken(t, testEdKey.Public())
+
+	tests := []struct {
+		name   string
+		method string
+		md     metadata.MD
+		now    time.Time
+	}{
+		{
+			"other method",
+			"/grpcauth.test.Test/TestOffline",
+			testDPoPMetadata(t, token, testEC256Key, testDPoPMethod, testDPoPURI),
+			time.Now(),
+		},
+		{
+			"other service uri",
+			testDPoPMethod,
+			testDPoPMetadata(t, token, testEC256Key, testDPoPMethod, "https://localhost:8000/other.Service"),
+			time.Now(),
+		},
+		{
+			"other host",
+			testDPoPMethod,
+			testDPoPMetadata(t, token, testEC256Key, testDPoPMethod, "https://attacker:8000/grpcauth.test.Test"),
+			time.Now(),
+		},
+		{
+			"token bound to other key",
+			testDPoPMethod,
+			testDPoPMetadata(t, otherToken, testEC256Key, testDPoPMethod, testDPoPURI),
+			time.Now(),
+		},
+		{
+			"proof too old",
+			testDPoPMethod,
+			testDPoPMetadata(t, token, testEC256Key, testDPoPMethod, testDPoPURI),
+			time.Now().Add(5 * time.Minute),
+		},
+		{
+			"missing proof",
+			testDPoPMethod,
+			metadata.MD{"authorization": []string{"DPoP " + token}},
+			time.Now(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := testDPoPAuthFunc(tt.now)(incomingContext(tt.method, tt.md)); status.Code(
+				err,
+			) != codes.Unauthenticated {
+				t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestDPoP_OtherToken(t *testing.T) {
+	token := testDPoPToken(t, testEC256Key.Public())
+	md := testDPoPMetadata(t, token, testEC256Key, testDPoPMethod, testDPoPURI)
+
+	// the proof was created for a different access token.
+	md.Set("authorization", "DPoP "+testDPoPToken(t, testEC256Key.Public())+"x")
+
+	if _, err := testDPoPAuthFunc(time.Now())(incomingContext(testDPoPMethod, md)); status.Code(
+		err,
+	) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestDPoP_BearerScheme(t *testing.T) {
+	verifier := testJWTVerifier()
+	authFunc := grpcauth.VerifyAuthenticatorsFunc(
+		grpcauth.NewBearerErrAuthenticator(verifier.VerifyBearer),
+		grpcauth.NewDPoPAuthenticator(grpcauth.DPoPConfig{Verify: verifier.VerifyBearer}),
+	)
+
+	// tokens bound to a DPoP key must not be accepted without a proof (RFC 9449 section 7.1).
+	md := metadata.Pairs("authorization", "Bearer "+testDPoPToken(t, testEC256Key.Public()))
+	if _, err := authFunc(incomingContext(testDPoPMethod, md)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+
+	token := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(nil))
+
+	md = metadata.Pairs("authorization", "Bearer "+token)
+	if _, err := authFunc(incomingContext(testDPoPMethod, md)); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+}
+
+func TestDPoP_Fail_SecurityLevel(t *testing.T) {
+	creds, err := grpcauth.NewDPoPCredentials("token", testEC256Key)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if _, err := creds.GetRequestMetadata(context.TODO(), testDPoPURI); err == nil {
+		t.Error("expected error to be returned, but error returned nil")
+	}
+}
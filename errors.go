@@ -0,0 +1,98 @@
+package grpcauth
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrInvalidCredentials is returned by verification functions when the credentials are not valid, it is
+	// reported to the client as codes.Unauthenticated.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrVerifierUnavailable is returned by verification functions when the backing service is unavailable, it is
+	// reported to the client as codes.Unavailable.
+	ErrVerifierUnavailable = errors.New("verifier unavailable")
+
+	// ErrCredentialsMissing is matched (using errors.Is) by the errors authentication functions return when the
+	// request does not carry any credentials they verify, it is reported to the client as codes.Unauthenticated.
+	//
+	// Methods with the AuthOptional mode are only allowed through unauthenticated for these errors.
+	ErrCredentialsMissing = errors.New("credentials missing")
+)
+
+// credentialsMissingError is the gRPC status returned when the request does not carry credentials, it matches
+// ErrCredentialsMissing.
+type credentialsMissingError struct {
+	status *status.Status
+}
+
+// credentialsMissing returns a codes.Unauthenticated status error with the message that matches
+// ErrCredentialsMissing.
+func credentialsMissing(msg string) error {
+	return &credentialsMissingError{status: status.New(codes.Unauthenticated, msg)}
+}
+
+func (e *credentialsMissingError) Error() string {
+	return e.status.Err().Error()
+}
+
+// GRPCStatus returns the status reported to the client.
+func (e *credentialsMissingError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Is returns true for ErrCredentialsMissing.
+func (e *credentialsMissingError) Is(target error) bool {
+	return target == ErrCredentialsMissing //nolint:errorlint // sentinel comparison.
+}
+
+// BasicErrFunc adapts an AuthVerifyBasicFunc to an AuthVerifyBasicErrFunc, a failed verification is reported
+// as ErrInvalidCredentials.
+func BasicErrFunc(basicAuth AuthVerifyBasicFunc) AuthVerifyBasicErrFunc {
+	return func(ctx context.Context, u, p string) (context.Context, string, error) {
+		if outCtx, user, ok := basicAuth(ctx, u, p); ok {
+			return outCtx, user, nil
+		}
+
+		return ctx, "", ErrInvalidCredentials
+	}
+}
+
+// BearerErrFunc adapts an AuthVerifyBearerFunc to an AuthVerifyBearerErrFunc, a failed verification is reported
+// as ErrInvalidCredentials.
+func BearerErrFunc(bearerAuth AuthVerifyBearerFunc) AuthVerifyBearerErrFunc {
+	return func(ctx context.Context, token string) (context.Context, string, bool, error) {
+		if outCtx, u, online, ok := bearerAuth(ctx, token); ok {
+			return outCtx, u, online, nil
+		}
+
+		return ctx, "", false, ErrInvalidCredentials
+	}
+}
+
+// verifierError converts an error returned from a verification function into the gRPC status returned to the
+// client.
+//
+// Errors that already carry a gRPC status are returned untouched, other errors are not exposed to the client.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func verifierError(scheme string, err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		return status.Errorf(codes.Unauthenticated, "authentication failed with %s authorization scheme", scheme)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, ErrVerifierUnavailable):
+		return status.Errorf(codes.Unavailable, "authentication unavailable for %s authorization scheme", scheme)
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Errorf(codes.Internal, "authentication error with %s authorization scheme", scheme)
+}
@@ -0,0 +1,136 @@
+package grpcauth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrors_BearerErrFunc(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode codes.Code
+	}{
+		{"invalid credentials", grpcauth.ErrInvalidCredentials, codes.Unauthenticated},
+		{
+			"wrapped invalid credentials",
+			fmt.Errorf("token revoked: %w", grpcauth.ErrInvalidCredentials),
+			codes.Unauthenticated,
+		},
+		{"verifier unavailable", grpcauth.ErrVerifierUnavailable, codes.Unavailable},
+		{
+			"wrapped verifier unavailable",
+			fmt.Errorf("dial tcp: %w", grpcauth.ErrVerifierUnavailable),
+			codes.Unavailable,
+		},
+		{"deadline exceeded", context.DeadlineExceeded, codes.DeadlineExceeded},
+		{"unknown error", errors.New("database exploded"), codes.Internal},
+		{"status passed through", status.Error(codes.ResourceExhausted, "slow down"), codes.ResourceExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bearerFunc := func(ctx context.Context, _ string) (context.Context, string, bool, error) {
+				return ctx, "", false, tt.err
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+				"authorization": []string{"Bearer token"},
+			})
+
+			authCtx, err := grpcauth.VerifyAuthorizationErrFunc(nil, bearerFunc)(ctx)
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Errorf("expected error code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+
+			if _, ok := grpcauth.PrincipalFromContext(authCtx); ok {
+				t.Error("expected principal not to be present in context")
+			}
+		})
+	}
+}
+
+func TestErrors_StatusMessageUntouched(t *testing.T) {
+	bearerFunc := func(ctx context.Context, _ string) (context.Context, string, bool, error) {
+		return ctx, "", false, status.Error(codes.PermissionDenied, "account suspended")
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Bearer token"},
+	})
+
+	_, err := grpcauth.VerifyAuthorizationErrFunc(nil, bearerFunc)(ctx)
+	if s := status.Convert(err); s.Message() != "account suspended" {
+		t.Errorf("expected error message to be '%s', received '%s'", "account suspended", s.Message())
+	}
+}
+
+func TestErrors_BasicErrFunc(t *testing.T) {
+	basicFunc := func(ctx context.Context, u, _ string) (context.Context, string, error) {
+		switch u {
+		case "valid-user":
+			return ctx, u, nil
+		case "offline-db":
+			return ctx, "", grpcauth.ErrVerifierUnavailable
+		}
+
+		return ctx, "", grpcauth.ErrInvalidCredentials
+	}
+
+	tests := []struct {
+		name, user   string
+		expectedCode codes.Code
+	}{
+		{"valid user", "valid-user", codes.OK},
+		{"invalid user", "invalid-user", codes.Unauthenticated},
+		{"database offline", "offline-db", codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+				"authorization": []string{
+					"Basic " + base64.StdEncoding.EncodeToString([]byte(tt.user+":pass")),
+				},
+			})
+
+			authCtx, err := grpcauth.VerifyAuthorizationErrFunc(basicFunc, nil)(ctx)
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Errorf("expected error code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+
+			if tt.expectedCode == codes.OK {
+				if v := authCtx.Value(grpcauth.Username); v != tt.user {
+					t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", tt.user, v)
+				}
+			}
+		})
+	}
+}
+
+func TestErrors_CredentialsMissing(t *testing.T) {
+	authFunc := grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc)
+
+	_, err := authFunc(metadata.NewIncomingContext(context.Background(), metadata.MD{}))
+	if !errors.Is(err, grpcauth.ErrCredentialsMissing) {
+		t.Errorf("expected error to be '%v', received '%v'", grpcauth.ErrCredentialsMissing, err)
+	}
+
+	if s, _ := status.FromError(err); s.Code() != codes.Unauthenticated || s.Message() != "authentication missing" {
+		t.Errorf("expected status to be '%s' 'authentication missing', received '%s' '%s'",
+			codes.Unauthenticated, s.Code(), s.Message())
+	}
+
+	_, err = authFunc(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer x")))
+	if errors.Is(err, grpcauth.ErrCredentialsMissing) {
+		t.Errorf("expected error to not be '%v', received '%v'", grpcauth.ErrCredentialsMissing, err)
+	}
+}
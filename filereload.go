@@ -0,0 +1,100 @@
+package grpcauth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileReloader reads a file and re-reads it in the background when its modification time or size changes.
+type fileReloader struct {
+	filename string
+	kind     string
+	parse    func(io.Reader) error
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newFileReloader(filename, kind string, parse func(io.Reader) error) *fileReloader {
+	return &fileReloader{
+		filename: filename,
+		kind:     kind,
+		parse:    parse,
+		stop:     make(chan struct{}),
+	}
+}
+
+// load reads and parses the file, parse is responsible for swapping in the new content once it is valid.
+func (f *fileReloader) load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fh, err := os.Open(f.filename)
+	if err != nil {
+		return fmt.Errorf("%w: unable to open %s file: %w", ErrVerifierUnavailable, f.kind, err)
+	}
+	defer fh.Close()
+
+	st, err := fh.Stat()
+	if err != nil {
+		return fmt.Errorf("%w: unable to stat %s file: %w", ErrVerifierUnavailable, f.kind, err)
+	}
+
+	if err := f.parse(fh); err != nil {
+		return err
+	}
+
+	f.modTime, f.size = st.ModTime(), st.Size()
+
+	return nil
+}
+
+// start checks the file for changes every interval until ctx is cancelled or close is called.
+func (f *fileReloader) start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				if f.changed() {
+					_ = f.load()
+				}
+			}
+		}
+	}()
+}
+
+func (f *fileReloader) changed() bool {
+	st, err := os.Stat(f.filename)
+	if err != nil {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return !st.ModTime().Equal(f.modTime) || st.Size() != f.size
+}
+
+func (f *fileReloader) close() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
@@ -0,0 +1,322 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.33.0
+// source: github.com/dosquad/go-grpcauth/grpcauth.proto
+
+package grpcauth
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Rule is the authentication and authorization requirement of a method.
+type Rule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Public allows the method to be called without authentication.
+	Public bool `protobuf:"varint,1,opt,name=public,proto3" json:"public,omitempty"`
+	// RequireOnline requires the request to be authenticated with online credentials.
+	RequireOnline bool `protobuf:"varint,2,opt,name=require_online,json=requireOnline,proto3" json:"require_online,omitempty"`
+	// Scopes the principal must have all of.
+	Scopes []string `protobuf:"bytes,3,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	// Roles the principal must have any of.
+	Roles         []string `protobuf:"bytes,4,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	mi := &file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Rule) GetPublic() bool {
+	if x != nil {
+		return x.Public
+	}
+	return false
+}
+
+func (x *Rule) GetRequireOnline() bool {
+	if x != nil {
+		return x.RequireOnline
+	}
+	return false
+}
+
+func (x *Rule) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *Rule) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+// Owner is the principal value a request field must match.
+type Owner struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Claim is the name of the principal claim the field must match, the subject is matched when empty.
+	Claim         string `protobuf:"bytes,1,opt,name=claim,proto3" json:"claim,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Owner) Reset() {
+	*x = Owner{}
+	mi := &file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Owner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Owner) ProtoMessage() {}
+
+func (x *Owner) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Owner.ProtoReflect.Descriptor instead.
+func (*Owner) Descriptor() ([]byte, []int) {
+	return file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Owner) GetClaim() string {
+	if x != nil {
+		return x.Claim
+	}
+	return ""
+}
+
+// Sensitive is the scopes required to read a response field.
+type Sensitive struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Scopes the principal must have any of to read the field.
+	Scopes []string `protobuf:"bytes,1,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	// Mask replaces string and bytes values that can not be read instead of clearing the field.
+	Mask          string `protobuf:"bytes,2,opt,name=mask,proto3" json:"mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sensitive) Reset() {
+	*x = Sensitive{}
+	mi := &file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sensitive) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sensitive) ProtoMessage() {}
+
+func (x *Sensitive) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sensitive.ProtoReflect.Descriptor instead.
+func (*Sensitive) Descriptor() ([]byte, []int) {
+	return file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Sensitive) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *Sensitive) GetMask() string {
+	if x != nil {
+		return x.Mask
+	}
+	return ""
+}
+
+var file_github_com_dosquad_go_grpcauth_grpcauth_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*Rule)(nil),
+		Field:         51234,
+		Name:          "grpcauth.rule",
+		Tag:           "bytes,51234,opt,name=rule",
+		Filename:      "github.com/dosquad/go-grpcauth/grpcauth.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Owner)(nil),
+		Field:         51235,
+		Name:          "grpcauth.owner",
+		Tag:           "bytes,51235,opt,name=owner",
+		Filename:      "github.com/dosquad/go-grpcauth/grpcauth.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Sensitive)(nil),
+		Field:         51236,
+		Name:          "grpcauth.sensitive",
+		Tag:           "bytes,51236,opt,name=sensitive",
+		Filename:      "github.com/dosquad/go-grpcauth/grpcauth.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// Rule is the authentication and authorization requirement of the method, it is read by RulePolicy.
+	//
+	// optional grpcauth.Rule rule = 51234;
+	E_Rule = &file_github_com_dosquad_go_grpcauth_grpcauth_proto_extTypes[0]
+)
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// Owner requires the request field to match the principal, it is read by OwnershipPolicy.
+	//
+	// optional grpcauth.Owner owner = 51235;
+	E_Owner = &file_github_com_dosquad_go_grpcauth_grpcauth_proto_extTypes[1]
+	// Sensitive clears the response field unless the principal has the scopes, it is read by RedactionPolicy.
+	//
+	// optional grpcauth.Sensitive sensitive = 51236;
+	E_Sensitive = &file_github_com_dosquad_go_grpcauth_grpcauth_proto_extTypes[2]
+)
+
+var File_github_com_dosquad_go_grpcauth_grpcauth_proto protoreflect.FileDescriptor
+
+const file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDesc = "" +
+	"\n" +
+	"-github.com/dosquad/go-grpcauth/grpcauth.proto\x12\bgrpcauth\x1a google/protobuf/descriptor.proto\"s\n" +
+	"\x04Rule\x12\x16\n" +
+	"\x06public\x18\x01 \x01(\bR\x06public\x12%\n" +
+	"\x0erequire_online\x18\x02 \x01(\bR\rrequireOnline\x12\x16\n" +
+	"\x06scopes\x18\x03 \x03(\tR\x06scopes\x12\x14\n" +
+	"\x05roles\x18\x04 \x03(\tR\x05roles\"\x1d\n" +
+	"\x05Owner\x12\x14\n" +
+	"\x05claim\x18\x01 \x01(\tR\x05claim\"7\n" +
+	"\tSensitive\x12\x16\n" +
+	"\x06scopes\x18\x01 \x03(\tR\x06scopes\x12\x12\n" +
+	"\x04mask\x18\x02 \x01(\tR\x04mask:D\n" +
+	"\x04rule\x12\x1e.google.protobuf.MethodOptions\x18\xa2\x90\x03 \x01(\v2\x0e.grpcauth.RuleR\x04rule:F\n" +
+	"\x05owner\x12\x1d.google.protobuf.FieldOptions\x18\xa3\x90\x03 \x01(\v2\x0f.grpcauth.OwnerR\x05owner:R\n" +
+	"\tsensitive\x12\x1d.google.protobuf.FieldOptions\x18\xa4\x90\x03 \x01(\v2\x13.grpcauth.SensitiveR\tsensitiveB)Z'github.com/dosquad/go-grpcauth;grpcauthb\x06proto3"
+
+var (
+	file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescOnce sync.Once
+	file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescData []byte
+)
+
+func file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescGZIP() []byte {
+	file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescOnce.Do(func() {
+		file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDesc), len(file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDesc)))
+	})
+	return file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDescData
+}
+
+var file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_github_com_dosquad_go_grpcauth_grpcauth_proto_goTypes = []any{
+	(*Rule)(nil),                       // 0: grpcauth.Rule
+	(*Owner)(nil),                      // 1: grpcauth.Owner
+	(*Sensitive)(nil),                  // 2: grpcauth.Sensitive
+	(*descriptorpb.MethodOptions)(nil), // 3: google.protobuf.MethodOptions
+	(*descriptorpb.FieldOptions)(nil),  // 4: google.protobuf.FieldOptions
+}
+var file_github_com_dosquad_go_grpcauth_grpcauth_proto_depIdxs = []int32{
+	3, // 0: grpcauth.rule:extendee -> google.protobuf.MethodOptions
+	4, // 1: grpcauth.owner:extendee -> google.protobuf.FieldOptions
+	4, // 2: grpcauth.sensitive:extendee -> google.protobuf.FieldOptions
+	0, // 3: grpcauth.rule:type_name -> grpcauth.Rule
+	1, // 4: grpcauth.owner:type_name -> grpcauth.Owner
+	2, // 5: grpcauth.sensitive:type_name -> grpcauth.Sensitive
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	3, // [3:6] is the sub-list for extension type_name
+	0, // [0:3] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_github_com_dosquad_go_grpcauth_grpcauth_proto_init() }
+func file_github_com_dosquad_go_grpcauth_grpcauth_proto_init() {
+	if File_github_com_dosquad_go_grpcauth_grpcauth_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDesc), len(file_github_com_dosquad_go_grpcauth_grpcauth_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 3,
+			NumServices:   0,
+		},
+		GoTypes:           file_github_com_dosquad_go_grpcauth_grpcauth_proto_goTypes,
+		DependencyIndexes: file_github_com_dosquad_go_grpcauth_grpcauth_proto_depIdxs,
+		MessageInfos:      file_github_com_dosquad_go_grpcauth_grpcauth_proto_msgTypes,
+		ExtensionInfos:    file_github_com_dosquad_go_grpcauth_grpcauth_proto_extTypes,
+	}.Build()
+	File_github_com_dosquad_go_grpcauth_grpcauth_proto = out.File
+	file_github_com_dosquad_go_grpcauth_grpcauth_proto_goTypes = nil
+	file_github_com_dosquad_go_grpcauth_grpcauth_proto_depIdxs = nil
+}
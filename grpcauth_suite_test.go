@@ -10,6 +10,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
 type testContextValue string
@@ -74,3 +75,22 @@ func dialTLSVerification(t *testing.T) grpc.DialOption {
 
 	return grpc.WithTransportCredentials(creds)
 }
+
+type testServerTransportStream struct {
+	method string
+}
+
+func (s *testServerTransportStream) Method() string                 { return s.method }
+func (s *testServerTransportStream) SetHeader(_ metadata.MD) error  { return nil }
+func (s *testServerTransportStream) SendHeader(_ metadata.MD) error { return nil }
+func (s *testServerTransportStream) SetTrailer(_ metadata.MD) error { return nil }
+
+// incomingContext returns a server context for the full method name with the incoming metadata.
+func incomingContext(method string, md metadata.MD) context.Context {
+	ctx := grpc.NewContextWithServerTransportStream(
+		context.Background(),
+		&testServerTransportStream{method: method},
+	)
+
+	return metadata.NewIncomingContext(ctx, md)
+}
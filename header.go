@@ -0,0 +1,112 @@
+package grpcauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// NewHeaderCredentials returns a new PerRPCCredentials implementation that sends the token in a custom metadata
+// header (eg. "x-api-key"), the prefix is prepended to the token when not empty.
+func NewHeaderCredentials(header, prefix, token string) credentials.PerRPCCredentials {
+	return &HeaderCreds{
+		header: strings.ToLower(header),
+		value:  prefix + token,
+	}
+}
+
+// HeaderCreds is the PerRPCCredentials implementation for tokens sent in a custom metadata header.
+type HeaderCreds struct {
+	header, value string
+}
+
+// GetRequestMetadata adds the custom header to the request.
+func (c *HeaderCreds) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	ri, _ := credentials.RequestInfoFromContext(ctx)
+	if err := credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity); err != nil {
+		return nil, fmt.Errorf("unable to transfer Header PerRPCCredentials: %w", err)
+	}
+
+	return map[string]string{c.header: c.value}, nil
+}
+
+// RequireTransportSecurity indicates whether the credentials requires
+// transport security.
+func (c *HeaderCreds) RequireTransportSecurity() bool {
+	return true
+}
+
+// HeaderConfig is the configuration for NewHeaderAuthenticator.
+type HeaderConfig struct {
+	// Header is the metadata header containing the token (eg. "x-api-key").
+	Header string
+
+	// Prefix is removed from the header value before verification (eg. "ApiKey "), values without the prefix are
+	// rejected. The whole value is the token when empty.
+	Prefix string
+
+	// Verify verifies the token, any bearer verification function can be used (eg. APIKeys.VerifyBearer).
+	Verify AuthVerifyBearerErrFunc
+}
+
+// HeaderAuthenticator authenticates requests using a token from a custom metadata header, for clients and gateways
+// that do not use the authorization header.
+//
+// The principal scheme is the header name.
+type HeaderAuthenticator struct {
+	header string
+	prefix string
+	verify AuthVerifyBearerErrFunc
+}
+
+// NewHeaderAuthenticator returns a new HeaderAuthenticator.
+func NewHeaderAuthenticator(cfg HeaderConfig) *HeaderAuthenticator {
+	return &HeaderAuthenticator{
+		header: strings.ToLower(cfg.Header),
+		prefix: cfg.Prefix,
+		verify: cfg.Verify,
+	}
+}
+
+// Verify authenticates the request using only the custom header, it can be used as the gRPC AuthFunc.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func (a *HeaderAuthenticator) Verify(ctx context.Context) (context.Context, error) {
+	values := a.values(ctx)
+	if len(values) == 0 {
+		return ctx, credentialsMissing("authentication missing")
+	}
+
+	if len(values) > 1 {
+		return ctx, verifierError(a.header, fmt.Errorf("%w: multiple %s headers", ErrInvalidCredentials, a.header))
+	}
+
+	token, ok := strings.CutPrefix(values[0], a.prefix)
+	if !ok || token == "" {
+		return ctx, verifierError(a.header, ErrInvalidCredentials)
+	}
+
+	return verifyToken(ctx, a.verify, a.header, token)
+}
+
+// Or returns a function that uses the custom header when it is present and authFunc otherwise.
+func (a *HeaderAuthenticator) Or(
+	authFunc func(ctx context.Context) (context.Context, error),
+) func(ctx context.Context) (context.Context, error) {
+	return func(ctx context.Context) (context.Context, error) {
+		if len(a.values(ctx)) > 0 {
+			return a.Verify(ctx)
+		}
+
+		return authFunc(ctx)
+	}
+}
+
+func (a *HeaderAuthenticator) values(ctx context.Context) []string {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return md.Get(a.header)
+}
@@ -0,0 +1,129 @@
+package grpcauth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestHeaderCredentials(t *testing.T) {
+	ctx := credentials.NewContextWithRequestInfo(context.Background(), credentials.RequestInfo{
+		AuthInfo: credentials.TLSInfo{
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		},
+	})
+
+	md, err := grpcauth.NewHeaderCredentials("X-API-Key", "ApiKey ", "secret").GetRequestMetadata(ctx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := md["x-api-key"]; v != "ApiKey secret" {
+		t.Errorf("expected header to be '%s', received '%s'", "ApiKey secret", v)
+	}
+
+	if _, err := grpcauth.NewHeaderCredentials("x-api-key", "", "secret").GetRequestMetadata(
+		context.TODO(),
+	); err == nil {
+		t.Error("expected error to be returned, but error returned nil")
+	}
+}
+
+func TestHeaderAuthenticator_Verify(t *testing.T) {
+	tests := []struct {
+		name         string
+		prefix       string
+		md           metadata.MD
+		expectedCode codes.Code
+		expectedUser string
+	}{
+		{"valid", "", metadata.Pairs("x-api-key", string(validOnlineToken)), codes.OK, "online-user"},
+		{"valid with prefix", "ApiKey ", metadata.Pairs("x-api-key", "ApiKey "+string(validOfflineToken)), codes.OK,
+			"offline-user"},
+		{"missing prefix", "ApiKey ", metadata.Pairs("x-api-key", string(validOnlineToken)), codes.Unauthenticated, ""},
+		{"invalid", "", metadata.Pairs("x-api-key", "invalid-token"), codes.Unauthenticated, ""},
+		{"missing", "", metadata.Pairs("authorization", "Bearer "+string(validOnlineToken)), codes.Unauthenticated,
+			""},
+		{"multiple", "", metadata.Pairs("x-api-key", string(validOnlineToken), "x-api-key", "invalid-token"),
+			codes.Unauthenticated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := grpcauth.NewHeaderAuthenticator(grpcauth.HeaderConfig{
+				Header: "X-API-Key",
+				Prefix: tt.prefix,
+				Verify: grpcauth.BearerErrFunc(bearerAuthFunc),
+			})
+
+			ctx, err := a.Verify(metadata.NewIncomingContext(context.Background(), tt.md))
+			if status.Code(err) != tt.expectedCode {
+				t.Fatalf("expected status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+
+			if tt.expectedCode != codes.OK {
+				return
+			}
+
+			if p, ok := grpcauth.PrincipalFromContext(ctx); !ok || p.Subject != tt.expectedUser ||
+				p.Scheme != "x-api-key" {
+				t.Errorf("expected principal '%s' with scheme 'x-api-key', received '%+v'", tt.expectedUser, p)
+			}
+		})
+	}
+}
+
+func TestHeaderAuthenticator_DPoPBound(t *testing.T) {
+	a := grpcauth.NewHeaderAuthenticator(grpcauth.HeaderConfig{
+		Header: "x-api-key",
+		Verify: testJWTVerifier().VerifyBearer,
+	})
+
+	// tokens bound to a DPoP key must not be accepted without a proof (RFC 9449 section 7.1).
+	md := metadata.Pairs("x-api-key", testDPoPToken(t, testEC256Key.Public()))
+	if _, err := a.Verify(metadata.NewIncomingContext(context.Background(), md)); status.Code(
+		err,
+	) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestHeaderAuthenticator_Or(t *testing.T) {
+	authFunc := grpcauth.NewHeaderAuthenticator(grpcauth.HeaderConfig{
+		Header: "x-api-key",
+		Verify: grpcauth.BearerErrFunc(bearerAuthFunc),
+	}).Or(grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc))
+
+	tests := []struct {
+		name   string
+		md     metadata.MD
+		scheme string
+	}{
+		{"header", metadata.Pairs("x-api-key", string(validOnlineToken)), "x-api-key"},
+		{"authorization", metadata.Pairs("authorization", "Bearer "+string(validOnlineToken)), "Bearer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := authFunc(metadata.NewIncomingContext(context.Background(), tt.md))
+			if err != nil {
+				t.Fatalf("expected error to be nil, returned '%v'", err)
+			}
+
+			if p, ok := grpcauth.PrincipalFromContext(ctx); !ok || p.Scheme != tt.scheme {
+				t.Errorf("expected scheme to be '%s', received '%+v'", tt.scheme, p)
+			}
+		})
+	}
+
+	if _, err := authFunc(metadata.NewIncomingContext(context.Background(), metadata.MD{})); status.Code(
+		err,
+	) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
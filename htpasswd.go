@@ -0,0 +1,172 @@
+package grpcauth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // required by the {SHA} htpasswd format.
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrHtpasswdInvalid is returned when an htpasswd file can not be parsed.
+var ErrHtpasswdInvalid = errors.New("invalid htpasswd file")
+
+//nolint:gochecknoglobals // generated once and shared by every htpasswd verifier.
+var (
+	htpasswdDummyOnce sync.Once
+	htpasswdDummyHash []byte
+)
+
+// HtpasswdConfig is the configuration for NewHtpasswdFile.
+type HtpasswdConfig struct {
+	// ReloadInterval is the interval the file is checked for changes at, the file is only read once when zero.
+	ReloadInterval time.Duration
+}
+
+// Htpasswd verifies Basic credentials against an Apache htpasswd file.
+//
+// Supported hash formats are bcrypt ("$2y$", "$2a$", "$2b$"), SHA1 ("{SHA}"), apr1-MD5 ("$apr1$") and crypt
+// SHA-256/SHA-512 ("$5$", "$6$"). Users with any other hash format can not authenticate.
+//
+// The file is swapped atomically on reload so in-flight verifications use the users loaded when they started.
+type Htpasswd struct {
+	users atomic.Pointer[map[string]string]
+	file  *fileReloader
+}
+
+// NewHtpasswd returns an Htpasswd verifier for the htpasswd file content.
+func NewHtpasswd(r io.Reader) (*Htpasswd, error) {
+	users, err := parseHtpasswd(r)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Htpasswd{}
+	h.users.Store(&users)
+
+	return h, nil
+}
+
+// NewHtpasswdFile returns an Htpasswd verifier for the file.
+//
+// The background reload started when ReloadInterval is set runs until ctx is cancelled or Close is called.
+func NewHtpasswdFile(ctx context.Context, filename string, cfg HtpasswdConfig) (*Htpasswd, error) {
+	h := &Htpasswd{}
+	h.file = newFileReloader(filename, "htpasswd", func(r io.Reader) error {
+		users, err := parseHtpasswd(r)
+		if err != nil {
+			return err
+		}
+
+		h.users.Store(&users)
+
+		return nil
+	})
+
+	if err := h.file.load(); err != nil {
+		return nil, err
+	}
+
+	h.file.start(ctx, cfg.ReloadInterval)
+
+	return h, nil
+}
+
+// VerifyBasic is an AuthVerifyBasicErrFunc that checks the username and password against the htpasswd file.
+//
+// Unknown users are compared against a dummy bcrypt hash so the response time does not reveal which users exist,
+// this is most effective when the file only contains bcrypt hashes.
+func (h *Htpasswd) VerifyBasic(ctx context.Context, u, p string) (context.Context, string, error) {
+	hashed, ok := (*h.users.Load())[u]
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(htpasswdDummy(), []byte(p))
+
+		return ctx, "", ErrInvalidCredentials
+	}
+
+	if !verifyHtpasswdHash(hashed, p) {
+		return ctx, "", ErrInvalidCredentials
+	}
+
+	return ctx, u, nil
+}
+
+// Reload reads the file, the existing users are retained if the file can not be read.
+func (h *Htpasswd) Reload() error {
+	if h.file == nil {
+		return nil
+	}
+
+	return h.file.load()
+}
+
+// Close stops the background reload.
+func (h *Htpasswd) Close() {
+	if h.file != nil {
+		h.file.close()
+	}
+}
+
+func parseHtpasswd(r io.Reader) (map[string]string, error) {
+	users := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		u, hashed, ok := strings.Cut(text, ":")
+		if !ok || u == "" || hashed == "" {
+			return nil, fmt.Errorf("%w: line %d", ErrHtpasswdInvalid, line)
+		}
+
+		users[u] = hashed
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHtpasswdInvalid, err)
+	}
+
+	return users, nil
+}
+
+func verifyHtpasswdHash(hashed, password string) bool {
+	switch {
+	case strings.HasPrefix(hashed, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)) == nil
+	case strings.HasPrefix(hashed, "{SHA}"):
+		sum := sha1.Sum([]byte(password)) //nolint:gosec // required by the {SHA} htpasswd format.
+		expected := []byte(base64.StdEncoding.EncodeToString(sum[:]))
+
+		return subtle.ConstantTimeCompare(expected, []byte(strings.TrimPrefix(hashed, "{SHA}"))) == 1
+	case strings.HasPrefix(hashed, "$apr1$"), strings.HasPrefix(hashed, "$5$"), strings.HasPrefix(hashed, "$6$"):
+		ok, err := verifyCrypt(hashed, password)
+
+		return err == nil && ok
+	}
+
+	return false
+}
+
+func htpasswdDummy() []byte {
+	htpasswdDummyOnce.Do(func() {
+		htpasswdDummyHash, _ = bcrypt.GenerateFromPassword(bytes.Repeat([]byte{'x'}, 16), bcrypt.DefaultCost)
+	})
+
+	return htpasswdDummyHash
+}
@@ -0,0 +1,165 @@
+package grpcauth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/metadata"
+)
+
+func testHtpasswdContent(t *testing.T) string {
+	t.Helper()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return strings.Join([]string{
+		"# service accounts",
+		"bcrypt-user:" + string(bcryptHash),
+		"sha1-user:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+		"apr1-user:$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1",
+		"sha256-user:$5$saltsalt$gOjOtoMpVhru2uyjeJSEc/JaLQWOXMNmlOnj6T4AtC.",
+		"sha256-rounds-user:$5$rounds=10000$saltsalt$a6WJS3V6B3leg7T3.ELC5.vcUmHOyFDvLaurLBy.mc8",
+		"sha512-user:$6$saltsalt$qFmFH.bQmmtXzyBY0s9v7Oicd2z4XSIecDzlB5KiA2/jctKu9YterLp8wwnSq.qc.eoxqOmSuNp2xS0ktL3nh/",
+		"plain-user:password",
+		"",
+	}, "\n")
+}
+
+func TestHtpasswd_VerifyBasic(t *testing.T) {
+	tests := []struct {
+		name, user, pass string
+		expectedPass     bool
+	}{
+		{"bcrypt", "bcrypt-user", "bcrypt-pass", true},
+		{"bcrypt wrong password", "bcrypt-user", "password", false},
+		{"sha1", "sha1-user", "password", true},
+		{"sha1 wrong password", "sha1-user", "passwort", false},
+		{"apr1", "apr1-user", "password", true},
+		{"apr1 wrong password", "apr1-user", "passwort", false},
+		{"sha256 crypt", "sha256-user", "password", true},
+		{"sha256 crypt with rounds", "sha256-rounds-user", "password", true},
+		{"sha256 crypt wrong password", "sha256-user", "passwort", false},
+		{"sha512 crypt", "sha512-user", "password", true},
+		{"sha512 crypt wrong password", "sha512-user", "passwort", false},
+		{"unsupported hash format", "plain-user", "password", false},
+		{"unknown user", "unknown-user", "password", false},
+	}
+
+	h, err := grpcauth.NewHtpasswd(strings.NewReader(testHtpasswdContent(t)))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, user, err := h.VerifyBasic(context.Background(), tt.user, tt.pass)
+			if tt.expectedPass && (err != nil || user != tt.user) {
+				t.Errorf("expected user '%s' to be verified, returned '%s' '%v'", tt.user, user, err)
+			}
+
+			if !tt.expectedPass && !errors.Is(err, grpcauth.ErrInvalidCredentials) {
+				t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+			}
+		})
+	}
+}
+
+func TestHtpasswd_Invalid(t *testing.T) {
+	if _, err := grpcauth.NewHtpasswd(strings.NewReader("missing-separator\n")); !errors.Is(
+		err,
+		grpcauth.ErrHtpasswdInvalid,
+	) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrHtpasswdInvalid, err)
+	}
+}
+
+func TestHtpasswd_FileReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(filename, []byte("sha1-user:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"), 0o600); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	h, err := grpcauth.NewHtpasswdFile(context.Background(), filename, grpcauth.HtpasswdConfig{
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+	defer h.Close()
+
+	if _, _, err := h.VerifyBasic(context.Background(), "sha1-user", "password"); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	if err := os.WriteFile(filename, []byte(testHtpasswdContent(t)), 0o600); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, _, err := h.VerifyBasic(context.Background(), "apr1-user", "password"); err == nil {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, _, err := h.VerifyBasic(context.Background(), "apr1-user", "password"); err != nil {
+		t.Errorf("expected error to be nil after reload, returned '%v'", err)
+	}
+
+	// an invalid file keeps the previously loaded users.
+	if err := os.WriteFile(filename, []byte("broken\n"), 0o600); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if err := h.Reload(); !errors.Is(err, grpcauth.ErrHtpasswdInvalid) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrHtpasswdInvalid, err)
+	}
+
+	if _, _, err := h.VerifyBasic(context.Background(), "apr1-user", "password"); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+}
+
+func TestHtpasswd_FileMissing(t *testing.T) {
+	_, err := grpcauth.NewHtpasswdFile(
+		context.Background(),
+		filepath.Join(t.TempDir(), "missing"),
+		grpcauth.HtpasswdConfig{},
+	)
+	if !errors.Is(err, grpcauth.ErrVerifierUnavailable) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrVerifierUnavailable, err)
+	}
+}
+
+func TestHtpasswd_VerifyAuthorization(t *testing.T) {
+	h, err := grpcauth.NewHtpasswd(strings.NewReader(testHtpasswdContent(t)))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Basic " + base64.StdEncoding.EncodeToString([]byte("sha512-user:password"))},
+	})
+
+	authCtx, err := grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewBasicErrAuthenticator(h.VerifyBasic))(ctx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "sha512-user" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "sha512-user", v)
+	}
+}
@@ -0,0 +1,232 @@
+package grpcauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const maxIntrospectionResponseSize = 1 << 20
+
+// IntrospectionClientAuth is the method used to authenticate with the introspection endpoint.
+type IntrospectionClientAuth int
+
+const (
+	// IntrospectionClientSecretBasic sends the client credentials using HTTP Basic authorization.
+	IntrospectionClientSecretBasic IntrospectionClientAuth = iota
+
+	// IntrospectionClientSecretPost sends the client credentials in the request body.
+	IntrospectionClientSecretPost
+)
+
+// IntrospectionConfig is the configuration for NewIntrospectionVerifier.
+type IntrospectionConfig struct {
+	// Endpoint is the URL of the authorization server's introspection endpoint.
+	Endpoint string
+
+	// ClientID used to authenticate with the introspection endpoint.
+	ClientID string
+
+	// ClientSecret used to authenticate with the introspection endpoint.
+	ClientSecret string
+
+	// ClientAuth is the method used to send the client credentials, defaults to IntrospectionClientSecretBasic.
+	ClientAuth IntrospectionClientAuth
+
+	// TokenTypeHint is sent as the "token_type_hint" parameter when set.
+	TokenTypeHint string
+
+	// CacheTTL is the maximum time an active token is cached for using a BearerCache, tokens are never cached
+	// beyond their "exp". Caching is disabled when zero.
+	CacheTTL time.Duration
+
+	// CacheSize is the maximum number of cached tokens, defaults to 10000.
+	CacheSize int
+
+	// HTTPClient used to call the introspection endpoint, defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// IntrospectionResponse is the response from an introspection endpoint (RFC 7662).
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Nbf       int64  `json:"nbf,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Aud       any    `json:"aud,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+
+	// Claims contains every member of the response, including extensions.
+	Claims map[string]any `json:"-"`
+}
+
+// Subject returns the "sub" member, falling back to "username" then "client_id".
+func (r *IntrospectionResponse) Subject() string {
+	switch {
+	case r.Sub != "":
+		return r.Sub
+	case r.Username != "":
+		return r.Username
+	}
+
+	return r.ClientID
+}
+
+// Scopes returns the space separated "scope" member.
+func (r *IntrospectionResponse) Scopes() []string {
+	return strings.Fields(r.Scope)
+}
+
+// IntrospectionVerifier verifies opaque bearer tokens with an OAuth2 token introspection endpoint (RFC 7662).
+type IntrospectionVerifier struct {
+	cfg   IntrospectionConfig
+	cache *BearerCache
+}
+
+// NewIntrospectionVerifier returns a new IntrospectionVerifier using the configuration.
+func NewIntrospectionVerifier(cfg IntrospectionConfig) *IntrospectionVerifier {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	v := &IntrospectionVerifier{
+		cfg: cfg,
+	}
+
+	if cfg.CacheTTL > 0 {
+		v.cache = NewBearerCache(v.verifyBearer, BearerCacheConfig{
+			TTL:        cfg.CacheTTL,
+			MaxEntries: cfg.CacheSize,
+			Now:        cfg.Now,
+		})
+	}
+
+	return v
+}
+
+// Introspect returns the introspection response for the token from the introspection endpoint, the cache is not
+// used.
+//
+// Inactive or expired tokens return an error wrapping ErrInvalidCredentials, failures calling the endpoint return
+// an error wrapping ErrVerifierUnavailable.
+func (v *IntrospectionVerifier) Introspect(ctx context.Context, token string) (*IntrospectionResponse, error) {
+	resp, err := v.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.Active {
+		return nil, fmt.Errorf("%w: token is not active", ErrInvalidCredentials)
+	}
+
+	if resp.Exp != 0 && !v.cfg.Now().Before(time.Unix(resp.Exp, 0)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	}
+
+	return resp, nil
+}
+
+// VerifyBearer is an AuthVerifyBearerErrFunc that introspects the token, principals are online when the response
+// was fetched from the introspection endpoint and offline when served from the cache.
+func (v *IntrospectionVerifier) VerifyBearer(ctx context.Context, token string) (context.Context, string, bool, error) {
+	if v.cache != nil {
+		return v.cache.VerifyBearer(ctx, token)
+	}
+
+	return v.verifyBearer(ctx, token)
+}
+
+func (v *IntrospectionVerifier) verifyBearer(ctx context.Context, token string) (context.Context, string, bool, error) {
+	resp, err := v.Introspect(ctx, token)
+	if err != nil {
+		return ctx, "", false, err
+	}
+
+	p := &Principal{
+		Subject: resp.Subject(),
+		Online:  true,
+		Scopes:  resp.Scopes(),
+		Claims:  resp.Claims,
+	}
+
+	if resp.Exp != 0 {
+		p.ExpiresAt = time.Unix(resp.Exp, 0)
+	}
+
+	ctx = NewContextWithPrincipal(ctx, p)
+
+	return ctx, resp.Subject(), true, nil
+}
+
+func (v *IntrospectionVerifier) introspect(ctx context.Context, token string) (*IntrospectionResponse, error) {
+	form := url.Values{"token": {token}}
+	if v.cfg.TokenTypeHint != "" {
+		form.Set("token_type_hint", v.cfg.TokenTypeHint)
+	}
+
+	if v.cfg.ClientAuth == IntrospectionClientSecretPost {
+		form.Set("client_id", v.cfg.ClientID)
+		form.Set("client_secret", v.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		v.cfg.Endpoint,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create introspection request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if v.cfg.ClientAuth == IntrospectionClientSecretBasic && v.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(v.cfg.ClientID), url.QueryEscape(v.cfg.ClientSecret))
+	}
+
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: introspection request failed: %w", ErrVerifierUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: introspection request failed: status %d", ErrVerifierUnavailable, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxIntrospectionResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read introspection response: %w", ErrVerifierUnavailable, err)
+	}
+
+	out := &IntrospectionResponse{}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &out.Claims); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,239 @@
+package grpcauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+)
+
+type testIntrospectionServer struct {
+	requests atomic.Int32
+	server   *httptest.Server
+}
+
+func newTestIntrospectionServer(t *testing.T, clientAuth grpcauth.IntrospectionClientAuth) *testIntrospectionServer {
+	t.Helper()
+
+	s := &testIntrospectionServer{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		user, pass, ok := r.BasicAuth()
+		if clientAuth == grpcauth.IntrospectionClientSecretPost {
+			user, pass, ok = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"), true
+		}
+
+		if !ok || user != "resource-server" || pass != "resource-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		resp := map[string]any{"active": false}
+
+		switch r.PostForm.Get("token") {
+		case "active-token":
+			resp = map[string]any{
+				"active":    true,
+				"sub":       "introspected-user",
+				"client_id": "mobile-app",
+				"scope":     "read write",
+				"exp":       testJWTNow.Add(time.Hour).Unix(),
+			}
+		case "short-lived-token":
+			resp = map[string]any{
+				"active": true,
+				"sub":    "introspected-user",
+				"exp":    testJWTNow.Add(time.Second).Unix(),
+			}
+		case "client-credentials-token":
+			resp = map[string]any{"active": true, "client_id": "batch-job"}
+		case "error-token":
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+
+	return s
+}
+
+func TestIntrospection_VerifyBearer(t *testing.T) {
+	for _, clientAuth := range []grpcauth.IntrospectionClientAuth{
+		grpcauth.IntrospectionClientSecretBasic,
+		grpcauth.IntrospectionClientSecretPost,
+	} {
+		s := newTestIntrospectionServer(t, clientAuth)
+		defer s.server.Close()
+
+		v := grpcauth.NewIntrospectionVerifier(grpcauth.IntrospectionConfig{
+			Endpoint:     s.server.URL,
+			ClientID:     "resource-server",
+			ClientSecret: "resource-secret",
+			ClientAuth:   clientAuth,
+			Now:          func() time.Time { return testJWTNow },
+		})
+
+		ctx, user, online, err := v.VerifyBearer(context.Background(), "active-token")
+		if err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+
+		if user != "introspected-user" {
+			t.Errorf("expected user to be '%s', received '%s'", "introspected-user", user)
+		}
+
+		if !online {
+			t.Error("expected online to be true")
+		}
+
+		p, _ := grpcauth.PrincipalFromContext(ctx)
+		if !p.HasScope("write") {
+			t.Errorf("expected principal to have scope 'write', received '%v'", p.Scopes)
+		}
+
+		if v, _ := p.Claim("client_id"); v != "mobile-app" {
+			t.Errorf("expected principal claim 'client_id' to be '%s', received '%v'", "mobile-app", v)
+		}
+	}
+}
+
+func TestIntrospection_Failures(t *testing.T) {
+	tests := []struct {
+		name, token, secret string
+		expectedErr         error
+	}{
+		{"inactive token", "inactive-token", "resource-secret", grpcauth.ErrInvalidCredentials},
+		{"endpoint error", "error-token", "resource-secret", grpcauth.ErrVerifierUnavailable},
+		{"client authentication failure", "active-token", "wrong-secret", grpcauth.ErrVerifierUnavailable},
+	}
+
+	s := newTestIntrospectionServer(t, grpcauth.IntrospectionClientSecretBasic)
+	defer s.server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := grpcauth.NewIntrospectionVerifier(grpcauth.IntrospectionConfig{
+				Endpoint:     s.server.URL,
+				ClientID:     "resource-server",
+				ClientSecret: tt.secret,
+				Now:          func() time.Time { return testJWTNow },
+			})
+
+			if _, _, _, err := v.VerifyBearer(context.Background(), tt.token); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error to be '%v', returned '%v'", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestIntrospection_ClientCredentialsSubject(t *testing.T) {
+	s := newTestIntrospectionServer(t, grpcauth.IntrospectionClientSecretBasic)
+	defer s.server.Close()
+
+	v := grpcauth.NewIntrospectionVerifier(grpcauth.IntrospectionConfig{
+		Endpoint:     s.server.URL,
+		ClientID:     "resource-server",
+		ClientSecret: "resource-secret",
+	})
+
+	if _, user, _, _ := v.VerifyBearer(context.Background(), "client-credentials-token"); user != "batch-job" {
+		t.Errorf("expected user to be '%s', received '%s'", "batch-job", user)
+	}
+}
+
+func TestIntrospection_Cache(t *testing.T) {
+	s := newTestIntrospectionServer(t, grpcauth.IntrospectionClientSecretBasic)
+	defer s.server.Close()
+
+	now := testJWTNow
+	v := grpcauth.NewIntrospectionVerifier(grpcauth.IntrospectionConfig{
+		Endpoint:     s.server.URL,
+		ClientID:     "resource-server",
+		ClientSecret: "resource-secret",
+		CacheTTL:     time.Minute,
+		Now:          func() time.Time { return now },
+	})
+
+	if _, _, online, _ := v.VerifyBearer(context.Background(), "active-token"); !online {
+		t.Error("expected first verification to be online")
+	}
+
+	if _, _, online, _ := v.VerifyBearer(context.Background(), "active-token"); online {
+		t.Error("expected cached verification to be offline")
+	}
+
+	if r := s.requests.Load(); r != 1 {
+		t.Errorf("expected introspection requests to be '%d', received '%d'", 1, r)
+	}
+
+	// cache entries are bounded by the token expiry.
+	if _, _, _, err := v.VerifyBearer(context.Background(), "short-lived-token"); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	now = now.Add(2 * time.Second)
+
+	if _, _, _, err := v.VerifyBearer(context.Background(), "short-lived-token"); !errors.Is(
+		err,
+		grpcauth.ErrInvalidCredentials,
+	) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+	}
+
+	if r := s.requests.Load(); r != 3 {
+		t.Errorf("expected introspection requests to be '%d', received '%d'", 3, r)
+	}
+
+	// cache entries are bounded by the cache TTL.
+	now = now.Add(time.Minute)
+
+	if _, _, online, _ := v.VerifyBearer(context.Background(), "active-token"); !online {
+		t.Error("expected verification after cache expiry to be online")
+	}
+}
+
+func TestIntrospection_CacheSize(t *testing.T) {
+	s := newTestIntrospectionServer(t, grpcauth.IntrospectionClientSecretBasic)
+	defer s.server.Close()
+
+	v := grpcauth.NewIntrospectionVerifier(grpcauth.IntrospectionConfig{
+		Endpoint:     s.server.URL,
+		ClientID:     "resource-server",
+		ClientSecret: "resource-secret",
+		CacheTTL:     time.Minute,
+		CacheSize:    1,
+		Now:          func() time.Time { return testJWTNow },
+	})
+
+	// the least recently used token is evicted when the cache is full.
+	for _, token := range []string{"active-token", "client-credentials-token", "client-credentials-token"} {
+		if _, _, _, err := v.VerifyBearer(context.Background(), token); err != nil {
+			t.Errorf("expected error to be nil, returned '%v'", err)
+		}
+	}
+
+	if _, _, online, _ := v.VerifyBearer(context.Background(), "active-token"); !online {
+		t.Error("expected verification of evicted token to be online")
+	}
+
+	if r := s.requests.Load(); r != 3 {
+		t.Errorf("expected introspection requests to be '%d', received '%d'", 3, r)
+	}
+}
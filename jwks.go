@@ -0,0 +1,472 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultJWKSMissRefreshInterval = time.Minute
+	maxJWKSResponseSize            = 1 << 20
+)
+
+// ErrJWKSInvalid is returned when a JWKS document can not be parsed.
+var ErrJWKSInvalid = errors.New("invalid jwks document")
+
+// JWKSFetchFunc returns the raw JWKS document.
+type JWKSFetchFunc = func(ctx context.Context) ([]byte, error)
+
+// JWKSConfig is the configuration for a JWKS key set.
+type JWKSConfig struct {
+	// RefreshInterval is the interval the key set is reloaded at, when zero the key set is only reloaded when
+	// a token uses an unknown key ID.
+	RefreshInterval time.Duration
+
+	// MissRefreshInterval is the minimum time between reloads triggered by tokens using an unknown key ID,
+	// defaults to one minute. A negative value disables reloading on unknown key IDs.
+	MissRefreshInterval time.Duration
+
+	// HTTPClient is used by NewJWKSFromURL, defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// JWKS is a JWTKeySet backed by a JSON Web Key Set document (RFC 7517).
+//
+// Keys are swapped atomically when the document is reloaded so verification never waits on a reload.
+type JWKS struct {
+	fetch       JWKSFetchFunc
+	cfg         JWKSConfig
+	keys        atomic.Pointer[jwksKeys]
+	lastRefresh atomic.Int64
+	refreshMu   sync.Mutex
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+type jwksKeys map[string][]jwksKey
+
+type jwksKey struct {
+	alg string
+	key any
+}
+
+// NewJWKS returns a static JWKS from the JSON document.
+func NewJWKS(data []byte) (*JWKS, error) {
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &JWKS{
+		cfg:  JWKSConfig{MissRefreshInterval: -1},
+		stop: make(chan struct{}),
+	}
+	k.keys.Store(&keys)
+
+	return k, nil
+}
+
+// NewJWKSFromFile returns a JWKS loaded from the file.
+//
+// The background reload started when RefreshInterval is set runs until ctx is cancelled or Close is called.
+func NewJWKSFromFile(ctx context.Context, filename string, cfg JWKSConfig) (*JWKS, error) {
+	return NewJWKSFromFunc(ctx, func(_ context.Context) ([]byte, error) {
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read jwks file: %w", ErrVerifierUnavailable, err)
+		}
+
+		return b, nil
+	}, cfg)
+}
+
+// NewJWKSFromURL returns a JWKS loaded from the HTTP endpoint.
+//
+// The background reload started when RefreshInterval is set runs until ctx is cancelled or Close is called.
+func NewJWKSFromURL(ctx context.Context, url string, cfg JWKSConfig) (*JWKS, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return NewJWKSFromFunc(ctx, func(ctx context.Context) ([]byte, error) {
+		return fetchJWKS(ctx, client, url)
+	}, cfg)
+}
+
+// NewJWKSFromFunc returns a JWKS loaded using the fetch function.
+//
+// The background reload started when RefreshInterval is set runs until ctx is cancelled or Close is called.
+func NewJWKSFromFunc(ctx context.Context, fetch JWKSFetchFunc, cfg JWKSConfig) (*JWKS, error) {
+	if cfg.MissRefreshInterval == 0 {
+		cfg.MissRefreshInterval = defaultJWKSMissRefreshInterval
+	}
+
+	k := &JWKS{
+		fetch: fetch,
+		cfg:   cfg,
+		stop:  make(chan struct{}),
+	}
+
+	if err := k.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if cfg.RefreshInterval > 0 {
+		go k.refreshLoop(ctx)
+	}
+
+	return k, nil
+}
+
+// Key returns the verification key for the key ID and algorithm, reloading the key set when the key ID is
+// unknown (at most once per MissRefreshInterval).
+func (k *JWKS) Key(ctx context.Context, kid, alg string) (any, error) {
+	if key, ok := k.lookup(kid, alg); ok {
+		return key, nil
+	}
+
+	if k.fetch == nil || k.cfg.MissRefreshInterval < 0 {
+		return nil, fmt.Errorf("%w: '%s'", ErrJWTKeyNotFound, kid)
+	}
+
+	if err := k.refreshAfter(ctx, k.cfg.MissRefreshInterval); err != nil {
+		return nil, err
+	}
+
+	if key, ok := k.lookup(kid, alg); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrJWTKeyNotFound, kid)
+}
+
+// Refresh reloads the key set, the existing keys are retained if the reload fails.
+func (k *JWKS) Refresh(ctx context.Context) error {
+	if k.fetch == nil {
+		return nil
+	}
+
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	return k.refresh(ctx)
+}
+
+// Close stops the background reload.
+func (k *JWKS) Close() {
+	k.stopOnce.Do(func() {
+		close(k.stop)
+	})
+}
+
+func (k *JWKS) lookup(kid, alg string) (any, bool) {
+	keys := *k.keys.Load()
+
+	candidates, ok := keys[kid]
+	if !ok && kid == "" && len(keys) == 1 {
+		for _, c := range keys {
+			candidates = c
+		}
+	}
+
+	for _, c := range candidates {
+		if c.alg == "" || c.alg == alg {
+			return c.key, true
+		}
+	}
+
+	return nil, false
+}
+
+// refreshAfter reloads the key set unless it has been reloaded within the interval.
+func (k *JWKS) refreshAfter(ctx context.Context, interval time.Duration) error {
+	if time.Since(time.Unix(0, k.lastRefresh.Load())) < interval {
+		return nil
+	}
+
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	// another caller may have reloaded while waiting for the lock.
+	if time.Since(time.Unix(0, k.lastRefresh.Load())) < interval {
+		return nil
+	}
+
+	return k.refresh(ctx)
+}
+
+func (k *JWKS) refresh(ctx context.Context) error {
+	// failed reloads are also rate limited so an unavailable source is not hammered.
+	k.lastRefresh.Store(time.Now().UnixNano())
+
+	data, err := k.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	k.keys.Store(&keys)
+
+	return nil
+}
+
+func (k *JWKS) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(k.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-k.stop:
+			return
+		case <-ticker.C:
+			_ = k.Refresh(ctx)
+		}
+	}
+}
+
+func fetchJWKS(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create jwks request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to fetch jwks: %w", ErrVerifierUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unable to fetch jwks: status %d", ErrVerifierUnavailable, resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read jwks: %w", ErrVerifierUnavailable, err)
+	}
+
+	return b, nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	K   string `json:"k,omitempty"`
+}
+
+func parseJWKS(data []byte) (jwksKeys, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWKSInvalid, err)
+	}
+
+	keys := jwksKeys{}
+
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key '%s': %w", ErrJWKSInvalid, jwk.Kid, err)
+		}
+
+		if key == nil {
+			continue
+		}
+
+		keys[jwk.Kid] = append(keys[jwk.Kid], jwksKey{alg: jwk.Alg, key: key})
+	}
+
+	return keys, nil
+}
+
+// publicKey returns the verification key, unsupported key types return nil.
+func (jwk jsonWebKey) publicKey() (any, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeJWKInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		return jwk.ecdsaPublicKey()
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, nil //nolint:nilnil // unsupported curves are skipped.
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(k) == 0 {
+			return nil, errors.New("invalid symmetric key")
+		}
+
+		return k, nil
+	}
+
+	return nil, nil //nolint:nilnil // unsupported key types are skipped.
+}
+
+func (jwk jsonWebKey) ecdsaPublicKey() (any, error) {
+	var (
+		curve elliptic.Curve
+		dh    ecdh.Curve
+	)
+
+	switch jwk.Crv {
+	case "P-256":
+		curve, dh = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, dh = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, dh = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, nil //nolint:nilnil // unsupported curves are skipped.
+	}
+
+	size := (curve.Params().BitSize + 7) / 8 //nolint:mnd // bits to bytes.
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil || len(x) != size {
+		return nil, errors.New("invalid ec x coordinate")
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil || len(y) != size {
+		return nil, errors.New("invalid ec y coordinate")
+	}
+
+	// validate the point is on the curve.
+	if _, err := dh.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, fmt.Errorf("invalid ec public key: %w", err)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid integer")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWKThumbprint returns the base64url encoded SHA-256 JWK thumbprint (RFC 7638) of the public key, as used by the
+// "jkt" confirmation method.
+//
+// Keys must be *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func JWKThumbprint(key crypto.PublicKey) (string, error) {
+	jwk, err := newJSONWebKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	// members are in lexicographic order and base64url values never need escaping.
+	var canonical string
+
+	switch jwk.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Crv, jwk.X, jwk.Y)
+	default:
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, jwk.Crv, jwk.X)
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// newJSONWebKey returns the JWK for the public key.
+func newJSONWebKey(key crypto.PublicKey) (jsonWebKey, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return jsonWebKey{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		dh, err := k.ECDH()
+		if err != nil {
+			return jsonWebKey{}, fmt.Errorf("invalid ec public key: %w", err)
+		}
+
+		// uncompressed point, 0x04 || x || y.
+		point := dh.Bytes()[1:]
+		size := len(point) / 2 //nolint:mnd // point is x || y.
+
+		return jsonWebKey{
+			Kty: "EC",
+			Crv: k.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(point[:size]),
+			Y:   base64.RawURLEncoding.EncodeToString(point[size:]),
+		}, nil
+	case ed25519.PublicKey:
+		return jsonWebKey{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	}
+
+	return jsonWebKey{}, fmt.Errorf("unsupported public key type %T", key)
+}
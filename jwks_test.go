@@ -0,0 +1,284 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+)
+
+func testJWK(kid string, key any) map[string]any {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]any{"kty": "RSA", "kid": kid, "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		x, y := make([]byte, size), make([]byte, size)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+
+		return map[string]any{"kty": "EC", "kid": kid, "crv": k.Curve.Params().Name, "x": b64(x), "y": b64(y)}
+	case ed25519.PublicKey:
+		return map[string]any{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(k)}
+	case []byte:
+		return map[string]any{"kty": "oct", "kid": kid, "k": b64(k)}
+	}
+
+	return nil
+}
+
+func testJWKSDocument(keys ...map[string]any) []byte {
+	b, _ := json.Marshal(map[string]any{"keys": keys})
+
+	return b
+}
+
+type testJWKSServer struct {
+	mu       sync.Mutex
+	document []byte
+	fetches  atomic.Int32
+	server   *httptest.Server
+}
+
+func newTestJWKSServer(document []byte) *testJWKSServer {
+	s := &testJWKSServer{document: document}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(s.document)
+	}))
+
+	return s
+}
+
+func (s *testJWKSServer) setDocument(document []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.document = document
+}
+
+func TestJWKS_KeyTypes(t *testing.T) {
+	jwks, err := grpcauth.NewJWKS(testJWKSDocument(
+		testJWK("rsa", &testRSAKey.PublicKey),
+		testJWK("ec256", &testEC256Key.PublicKey),
+		testJWK("ec384", &testEC384Key.PublicKey),
+		testJWK("ec521", &testEC521Key.PublicKey),
+		testJWK("ed", testEdKey.Public()),
+		testJWK("hmac", testHMACKey),
+	))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	v := grpcauth.NewJWTVerifier(grpcauth.JWTVerifierConfig{
+		Keys: jwks,
+		Now:  func() time.Time { return testJWTNow },
+	})
+
+	tests := []struct {
+		alg, kid string
+		key      any
+	}{
+		{grpcauth.JWTAlgRS256, "rsa", testRSAKey},
+		{grpcauth.JWTAlgPS384, "rsa", testRSAKey},
+		{grpcauth.JWTAlgES256, "ec256", testEC256Key},
+		{grpcauth.JWTAlgES384, "ec384", testEC384Key},
+		{grpcauth.JWTAlgES512, "ec521", testEC521Key},
+		{grpcauth.JWTAlgEdDSA, "ed", testEdKey},
+		{grpcauth.JWTAlgHS256, "hmac", testHMACKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			token := signTestJWT(t, tt.alg, tt.key, map[string]any{"kid": tt.kid}, testJWTClaims(nil))
+			if _, err := v.Verify(context.Background(), token); err != nil {
+				t.Errorf("expected error to be nil, returned '%v'", err)
+			}
+		})
+	}
+}
+
+func TestJWKS_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		document []byte
+	}{
+		{"not json", []byte("not-json")},
+		{"invalid rsa modulus", testJWKSDocument(map[string]any{"kty": "RSA", "kid": "a", "n": "!!", "e": "AQAB"})},
+		{"point not on curve", testJWKSDocument(map[string]any{
+			"kty": "EC",
+			"kid": "a",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+			"y":   base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+		})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := grpcauth.NewJWKS(tt.document); !errors.Is(err, grpcauth.ErrJWKSInvalid) {
+				t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrJWKSInvalid, err)
+			}
+		})
+	}
+}
+
+func TestJWKS_SkipsEncryptionKeys(t *testing.T) {
+	enc := testJWK("enc", &testRSAKey.PublicKey)
+	enc["use"] = "enc"
+
+	jwks, err := grpcauth.NewJWKS(testJWKSDocument(enc))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if _, err := jwks.Key(context.Background(), "enc", grpcauth.JWTAlgRS256); !errors.Is(
+		err,
+		grpcauth.ErrJWTKeyNotFound,
+	) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrJWTKeyNotFound, err)
+	}
+}
+
+func TestJWKS_File(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(filename, testJWKSDocument(testJWK("rsa", &testRSAKey.PublicKey)), 0o600); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	jwks, err := grpcauth.NewJWKSFromFile(context.Background(), filename, grpcauth.JWKSConfig{})
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+	defer jwks.Close()
+
+	if _, err := jwks.Key(context.Background(), "rsa", grpcauth.JWTAlgRS256); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+}
+
+func TestJWKS_URL_RefreshOnUnknownKid(t *testing.T) {
+	s := newTestJWKSServer(testJWKSDocument(testJWK("old", &testEC256Key.PublicKey)))
+	defer s.server.Close()
+
+	jwks, err := grpcauth.NewJWKSFromURL(context.Background(), s.server.URL, grpcauth.JWKSConfig{
+		MissRefreshInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+	defer jwks.Close()
+
+	s.setDocument(testJWKSDocument(testJWK("new", &testEC384Key.PublicKey)))
+
+	if _, err := jwks.Key(context.Background(), "new", grpcauth.JWTAlgES384); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := s.fetches.Load(); v != 2 {
+		t.Errorf("expected jwks to be fetched '%d' times, received '%d'", 2, v)
+	}
+}
+
+func TestJWKS_URL_RefreshRateLimited(t *testing.T) {
+	s := newTestJWKSServer(testJWKSDocument(testJWK("old", &testEC256Key.PublicKey)))
+	defer s.server.Close()
+
+	jwks, err := grpcauth.NewJWKSFromURL(context.Background(), s.server.URL, grpcauth.JWKSConfig{
+		MissRefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+	defer jwks.Close()
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := jwks.Key(context.Background(), "unknown", grpcauth.JWTAlgES256); !errors.Is(
+				err,
+				grpcauth.ErrJWTKeyNotFound,
+			) {
+				t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrJWTKeyNotFound, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if v := s.fetches.Load(); v != 1 {
+		t.Errorf("expected jwks to be fetched '%d' times, received '%d'", 1, v)
+	}
+}
+
+func TestJWKS_URL_PeriodicRefresh(t *testing.T) {
+	s := newTestJWKSServer(testJWKSDocument(testJWK("old", &testEC256Key.PublicKey)))
+	defer s.server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jwks, err := grpcauth.NewJWKSFromURL(ctx, s.server.URL, grpcauth.JWKSConfig{
+		RefreshInterval:     10 * time.Millisecond,
+		MissRefreshInterval: -1,
+	})
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+	defer jwks.Close()
+
+	s.setDocument(testJWKSDocument(testJWK("new", &testEC256Key.PublicKey)))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := jwks.Key(context.Background(), "new", grpcauth.JWTAlgES256); err == nil {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := jwks.Key(context.Background(), "new", grpcauth.JWTAlgES256); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	if _, err := jwks.Key(context.Background(), "old", grpcauth.JWTAlgES256); err == nil {
+		t.Error("expected error to be returned, but error returned nil")
+	}
+}
+
+func TestJWKS_URL_Unavailable(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	_, err := grpcauth.NewJWKSFromURL(context.Background(), s.URL, grpcauth.JWKSConfig{})
+	if !errors.Is(err, grpcauth.ErrVerifierUnavailable) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrVerifierUnavailable, err)
+	}
+}
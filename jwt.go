@@ -0,0 +1,438 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWT signature algorithms supported by the JWT verifier.
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgHS384 = "HS384"
+	JWTAlgHS512 = "HS512"
+	JWTAlgRS256 = "RS256"
+	JWTAlgRS384 = "RS384"
+	JWTAlgRS512 = "RS512"
+	JWTAlgPS256 = "PS256"
+	JWTAlgPS384 = "PS384"
+	JWTAlgPS512 = "PS512"
+	JWTAlgES256 = "ES256"
+	JWTAlgES384 = "ES384"
+	JWTAlgES512 = "ES512"
+	JWTAlgEdDSA = "EdDSA"
+)
+
+// ErrJWTKeyNotFound is returned by a JWTKeySet when there is no key matching the key ID.
+var ErrJWTKeyNotFound = errors.New("jwt key not found")
+
+// JWTKeySet provides the keys used to verify JWT signatures.
+//
+// Keys are returned as []byte for HMAC algorithms, *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+type JWTKeySet interface {
+	// Key returns the verification key for the key ID (which may be empty) and algorithm.
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// JWTKeys is a local JWTKeySet of verification keys indexed by key ID.
+//
+// When a token does not specify a key ID and the set contains a single key, that key is used.
+type JWTKeys map[string]any
+
+// Key returns the verification key for the key ID.
+func (k JWTKeys) Key(_ context.Context, kid, _ string) (any, error) {
+	if key, ok := k[kid]; ok {
+		return key, nil
+	}
+
+	if kid == "" && len(k) == 1 {
+		for _, key := range k {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrJWTKeyNotFound, kid)
+}
+
+// JWTVerifierConfig is the configuration for NewJWTVerifier.
+type JWTVerifierConfig struct {
+	// Keys used to verify token signatures.
+	Keys JWTKeySet
+
+	// Algorithms that are accepted, when empty all supported algorithms are accepted. The algorithm must also
+	// match the type of the key returned from Keys.
+	Algorithms []string
+
+	// Issuer that must match the "iss" claim, ignored when empty.
+	Issuer string
+
+	// Audience contains the accepted audiences, the "aud" claim must contain at least one of them, ignored when
+	// empty.
+	Audience []string
+
+	// Leeway is the allowed clock skew when validating the "exp", "nbf" and "iat" claims.
+	Leeway time.Duration
+
+	// RequireExpiry rejects tokens without an "exp" claim, when false tokens without one never expire.
+	RequireExpiry bool
+
+	// Types contains the accepted "typ" header values (eg. "at+jwt" for RFC 9068 access tokens), so other kinds
+	// of JWT signed by the same issuer (eg. ID tokens) are rejected. Values are compared case-insensitively and the
+	// "application/" prefix is optional, ignored when empty.
+	Types []string
+
+	// Online marks principals authenticated with the verifier as online, JWTs are verified locally so this
+	// defaults to false.
+	Online bool
+
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// JWTVerifier verifies JWT bearer tokens.
+type JWTVerifier struct {
+	cfg JWTVerifierConfig
+}
+
+// NewJWTVerifier returns a new JWTVerifier using the configuration.
+func NewJWTVerifier(cfg JWTVerifierConfig) *JWTVerifier {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &JWTVerifier{
+		cfg: cfg,
+	}
+}
+
+// JWTClaims are the claims of a verified JWT.
+type JWTClaims map[string]any
+
+// Subject returns the "sub" claim.
+func (c JWTClaims) Subject() string {
+	s, _ := c["sub"].(string)
+
+	return s
+}
+
+// Scopes returns the scopes from the "scope" (space separated) or "scp" claims.
+func (c JWTClaims) Scopes() []string {
+	if s, ok := c["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+
+	return claimStrings(c["scp"])
+}
+
+// Roles returns the "roles" claim.
+func (c JWTClaims) Roles() []string {
+	return claimStrings(c["roles"])
+}
+
+// Verify validates the token signature and claims, returning the claims when the token is valid.
+//
+// Invalid tokens return an error wrapping ErrInvalidCredentials, errors from the key set are returned unchanged
+// (unless the key was not found).
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (JWTClaims, error) {
+	header, claims, signed, sig, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, _ := header["alg"].(string)
+	if len(v.cfg.Algorithms) > 0 && !slices.Contains(v.cfg.Algorithms, alg) {
+		return nil, fmt.Errorf("%w: jwt algorithm '%s' not accepted", ErrInvalidCredentials, alg)
+	}
+
+	if _, ok := header["crit"]; ok {
+		return nil, fmt.Errorf("%w: jwt critical headers are not supported", ErrInvalidCredentials)
+	}
+
+	if typ, _ := header["typ"].(string); len(v.cfg.Types) > 0 && !jwtTypeAccepted(v.cfg.Types, typ) {
+		return nil, fmt.Errorf("%w: jwt type '%s' not accepted", ErrInvalidCredentials, typ)
+	}
+
+	kid, _ := header["kid"].(string)
+
+	key, err := v.cfg.Keys.Key(ctx, kid, alg)
+	if errors.Is(err, ErrJWTKeyNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyJWTSignature(alg, key, signed, sig); err != nil {
+		return nil, err
+	}
+
+	if err := v.verifyClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// VerifyBearer is an AuthVerifyBearerErrFunc that verifies the token, the "sub" claim is used as the username and
+// the scopes, roles and claims are attached to the principal.
+func (v *JWTVerifier) VerifyBearer(ctx context.Context, token string) (context.Context, string, bool, error) {
+	claims, err := v.Verify(ctx, token)
+	if err != nil {
+		return ctx, "", false, err
+	}
+
+	p := &Principal{
+		Subject: claims.Subject(),
+		Online:  v.cfg.Online,
+		Scopes:  claims.Scopes(),
+		Roles:   claims.Roles(),
+		Claims:  claims,
+	}
+
+	if exp, ok := claimTime(claims["exp"]); ok {
+		p.ExpiresAt = exp
+	}
+
+	ctx = NewContextWithPrincipal(ctx, p)
+
+	return ctx, claims.Subject(), v.cfg.Online, nil
+}
+
+func (v *JWTVerifier) verifyClaims(claims JWTClaims) error {
+	now := v.cfg.Now()
+
+	if claims.Subject() == "" {
+		return fmt.Errorf("%w: jwt missing sub claim", ErrInvalidCredentials)
+	}
+
+	times := map[string]time.Time{}
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		if c, present := claims[name]; present {
+			t, ok := claimTime(c)
+			if !ok {
+				return fmt.Errorf("%w: jwt %s claim malformed", ErrInvalidCredentials, name)
+			}
+
+			times[name] = t
+		}
+	}
+
+	if _, ok := times["exp"]; !ok && v.cfg.RequireExpiry {
+		return fmt.Errorf("%w: jwt missing exp claim", ErrInvalidCredentials)
+	}
+
+	if exp, ok := times["exp"]; ok && !now.Before(exp.Add(v.cfg.Leeway)) {
+		return fmt.Errorf("%w: jwt expired", ErrInvalidCredentials)
+	}
+
+	if nbf, ok := times["nbf"]; ok && now.Add(v.cfg.Leeway).Before(nbf) {
+		return fmt.Errorf("%w: jwt not valid yet", ErrInvalidCredentials)
+	}
+
+	if iat, ok := times["iat"]; ok && now.Add(v.cfg.Leeway).Before(iat) {
+		return fmt.Errorf("%w: jwt issued in the future", ErrInvalidCredentials)
+	}
+
+	if v.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+			return fmt.Errorf("%w: jwt issuer '%s' not accepted", ErrInvalidCredentials, iss)
+		}
+	}
+
+	if len(v.cfg.Audience) > 0 {
+		aud := claimStrings(claims["aud"])
+		if !slices.ContainsFunc(aud, func(a string) bool { return slices.Contains(v.cfg.Audience, a) }) {
+			return fmt.Errorf("%w: jwt audience not accepted", ErrInvalidCredentials)
+		}
+	}
+
+	return nil
+}
+
+// jwtTypeAccepted returns true when the "typ" header matches one of the accepted types (RFC 8725 section 3.11).
+func jwtTypeAccepted(types []string, typ string) bool {
+	typ = strings.TrimPrefix(strings.ToLower(typ), "application/")
+
+	return slices.ContainsFunc(types, func(t string) bool {
+		return strings.TrimPrefix(strings.ToLower(t), "application/") == typ
+	})
+}
+
+//nolint:mnd // expected set length based on format.
+func parseJWT(token string) (map[string]any, JWTClaims, string, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, "", nil, fmt.Errorf("%w: malformed jwt", ErrInvalidCredentials)
+	}
+
+	var header map[string]any
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, nil, "", nil, err
+	}
+
+	var claims JWTClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, nil, "", nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, "", nil, fmt.Errorf("%w: malformed jwt signature", ErrInvalidCredentials)
+	}
+
+	return header, claims, parts[0] + "." + parts[1], sig, nil
+}
+
+func decodeJWTSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: malformed jwt", ErrInvalidCredentials)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: malformed jwt", ErrInvalidCredentials)
+	}
+
+	return nil
+}
+
+func jwtHash(alg string) (crypto.Hash, bool) {
+	if len(alg) != 5 { //nolint:mnd // algorithm names are 5 characters, eg. "RS256".
+		return 0, false
+	}
+
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	}
+
+	return 0, false
+}
+
+func verifyJWTSignature(alg string, key any, signed string, sig []byte) error {
+	errInvalid := fmt.Errorf("%w: jwt signature invalid", ErrInvalidCredentials)
+
+	if alg == JWTAlgEdDSA {
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, []byte(signed), sig) {
+			return errInvalid
+		}
+
+		return nil
+	}
+
+	hash, ok := jwtHash(alg)
+	if !ok {
+		return fmt.Errorf("%w: jwt algorithm '%s' not supported", ErrInvalidCredentials, alg)
+	}
+
+	var h []byte
+
+	switch hash {
+	case crypto.SHA384:
+		s := sha512.Sum384([]byte(signed))
+		h = s[:]
+	case crypto.SHA512:
+		s := sha512.Sum512([]byte(signed))
+		h = s[:]
+	default:
+		s := sha256.Sum256([]byte(signed))
+		h = s[:]
+	}
+
+	var valid bool
+
+	switch alg[:2] {
+	case "HS":
+		if k, keyOk := key.([]byte); keyOk && len(k) > 0 {
+			mac := hmac.New(hash.New, k)
+			mac.Write([]byte(signed))
+			valid = hmac.Equal(mac.Sum(nil), sig)
+		}
+	case "RS":
+		if k, keyOk := key.(*rsa.PublicKey); keyOk {
+			valid = rsa.VerifyPKCS1v15(k, hash, h, sig) == nil
+		}
+	case "PS":
+		if k, keyOk := key.(*rsa.PublicKey); keyOk {
+			valid = rsa.VerifyPSS(k, hash, h, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case "ES":
+		if k, keyOk := key.(*ecdsa.PublicKey); keyOk && (k.Curve.Params().BitSize+7)/8*2 == len(sig) {
+			size := len(sig) / 2 //nolint:mnd // signature is r || s.
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			valid = jwtCurveMatches(alg, k) && ecdsa.Verify(k, h, r, s)
+		}
+	default:
+		return fmt.Errorf("%w: jwt algorithm '%s' not supported", ErrInvalidCredentials, alg)
+	}
+
+	if !valid {
+		return errInvalid
+	}
+
+	return nil
+}
+
+func jwtCurveMatches(alg string, k *ecdsa.PublicKey) bool {
+	switch alg {
+	case JWTAlgES256:
+		return k.Curve.Params().Name == "P-256"
+	case JWTAlgES384:
+		return k.Curve.Params().Name == "P-384"
+	case JWTAlgES512:
+		return k.Curve.Params().Name == "P-521"
+	}
+
+	return false
+}
+
+func claimTime(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	sec := int64(f)
+
+	return time.Unix(sec, int64((f-float64(sec))*float64(time.Second))), true
+}
+
+func claimStrings(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, i := range t {
+			if s, ok := i.(string); ok {
+				out = append(out, s)
+			}
+		}
+
+		return out
+	}
+
+	return nil
+}
@@ -0,0 +1,342 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/metadata"
+)
+
+//nolint:gochecknoglobals // test code
+var (
+	testHMACKey   = []byte("jwt-test-hmac-secret-key-32-bytes")
+	testRSAKey    = mustKey(rsa.GenerateKey(rand.Reader, 2048))
+	testEC256Key  = mustKey(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	testEC384Key  = mustKey(ecdsa.GenerateKey(elliptic.P384(), rand.Reader))
+	testEC521Key  = mustKey(ecdsa.GenerateKey(elliptic.P521(), rand.Reader))
+	_, testEdKey  = mustEdKey()
+	testJWTNow    = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	testJWTIssuer = "https://issuer.example.com"
+)
+
+func mustKey[T any](k T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+
+	return k
+}
+
+func mustEdKey() (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	return pub, priv
+}
+
+func signTestJWT(t *testing.T, alg string, key any, header, claims map[string]any) string {
+	t.Helper()
+
+	h := map[string]any{"alg": alg, "typ": "JWT"}
+	for k, v := range header {
+		h[k] = v
+	}
+
+	hb, _ := json.Marshal(h)
+	cb, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(cb)
+
+	hash := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}[alg[len(alg)-3:]]
+
+	var (
+		sig []byte
+		err error
+	)
+
+	switch {
+	case alg == grpcauth.JWTAlgEdDSA:
+		sig = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+	case alg[:2] == "HS":
+		mac := hmac.New(hash.New, key.([]byte))
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case alg[:2] == "RS":
+		hh := hash.New()
+		hh.Write([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), hash, hh.Sum(nil))
+	case alg[:2] == "PS":
+		hh := hash.New()
+		hh.Write([]byte(signed))
+		sig, err = rsa.SignPSS(
+			rand.Reader,
+			key.(*rsa.PrivateKey),
+			hash,
+			hh.Sum(nil),
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash},
+		)
+	case alg[:2] == "ES":
+		hh := hash.New()
+		hh.Write([]byte(signed))
+		k := key.(*ecdsa.PrivateKey)
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, hh.Sum(nil))
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, size*2)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func testJWTClaims(overrides map[string]any) map[string]any {
+	claims := map[string]any{
+		"sub":   "jwt-user",
+		"iss":   testJWTIssuer,
+		"aud":   []string{"grpcauth-test"},
+		"iat":   testJWTNow.Add(-time.Minute).Unix(),
+		"nbf":   testJWTNow.Add(-time.Minute).Unix(),
+		"exp":   testJWTNow.Add(time.Hour).Unix(),
+		"scope": "read write",
+		"roles": []string{"admin"},
+	}
+
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+
+			continue
+		}
+
+		claims[k] = v
+	}
+
+	return claims
+}
+
+func testJWTVerifier() *grpcauth.JWTVerifier {
+	return grpcauth.NewJWTVerifier(grpcauth.JWTVerifierConfig{
+		Keys: grpcauth.JWTKeys{
+			"hmac":  testHMACKey,
+			"rsa":   &testRSAKey.PublicKey,
+			"ec256": &testEC256Key.PublicKey,
+			"ec384": &testEC384Key.PublicKey,
+			"ec521": &testEC521Key.PublicKey,
+			"ed":    testEdKey.Public(),
+		},
+		Issuer:   testJWTIssuer,
+		Audience: []string{"grpcauth-test"},
+		Leeway:   30 * time.Second,
+		Now:      func() time.Time { return testJWTNow },
+	})
+}
+
+func TestJWT_Algorithms(t *testing.T) {
+	tests := []struct {
+		alg, kid string
+		key      any
+	}{
+		{grpcauth.JWTAlgHS256, "hmac", testHMACKey},
+		{grpcauth.JWTAlgHS384, "hmac", testHMACKey},
+		{grpcauth.JWTAlgHS512, "hmac", testHMACKey},
+		{grpcauth.JWTAlgRS256, "rsa", testRSAKey},
+		{grpcauth.JWTAlgRS384, "rsa", testRSAKey},
+		{grpcauth.JWTAlgRS512, "rsa", testRSAKey},
+		{grpcauth.JWTAlgPS256, "rsa", testRSAKey},
+		{grpcauth.JWTAlgPS384, "rsa", testRSAKey},
+		{grpcauth.JWTAlgPS512, "rsa", testRSAKey},
+		{grpcauth.JWTAlgES256, "ec256", testEC256Key},
+		{grpcauth.JWTAlgES384, "ec384", testEC384Key},
+		{grpcauth.JWTAlgES512, "ec521", testEC521Key},
+		{grpcauth.JWTAlgEdDSA, "ed", testEdKey},
+	}
+
+	v := testJWTVerifier()
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			token := signTestJWT(t, tt.alg, tt.key, map[string]any{"kid": tt.kid}, testJWTClaims(nil))
+
+			claims, err := v.Verify(context.Background(), token)
+			if err != nil {
+				t.Fatalf("expected error to be nil, returned '%v'", err)
+			}
+
+			if claims.Subject() != "jwt-user" {
+				t.Errorf("expected subject to be '%s', received '%s'", "jwt-user", claims.Subject())
+			}
+		})
+	}
+}
+
+func TestJWT_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+	}{
+		{"malformed", func(*testing.T) string { return "not-a-jwt" }},
+		{"bad signature", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, []byte("wrong-key"), map[string]any{"kid": "hmac"},
+				testJWTClaims(nil))
+		}},
+		{"expired", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"},
+				testJWTClaims(map[string]any{"exp": testJWTNow.Add(-time.Minute).Unix()}))
+		}},
+		{"not yet valid", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"},
+				testJWTClaims(map[string]any{"nbf": testJWTNow.Add(time.Minute).Unix()}))
+		}},
+		{"issued in the future", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"},
+				testJWTClaims(map[string]any{"iat": testJWTNow.Add(time.Minute).Unix()}))
+		}},
+		{"wrong issuer", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"},
+				testJWTClaims(map[string]any{"iss": "https://evil.example.com"}))
+		}},
+		{"wrong audience", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"},
+				testJWTClaims(map[string]any{"aud": "other"}))
+		}},
+		{"missing subject", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"},
+				testJWTClaims(map[string]any{"sub": nil}))
+		}},
+		{"unknown key", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "unknown"},
+				testJWTClaims(nil))
+		}},
+		{"algorithm does not match key", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "rsa"},
+				testJWTClaims(nil))
+		}},
+		{"curve does not match algorithm", func(t *testing.T) string {
+			return signTestJWT(t, grpcauth.JWTAlgES256, testEC256Key, map[string]any{"kid": "ec384"},
+				testJWTClaims(nil))
+		}},
+		{"none algorithm", func(t *testing.T) string {
+			h, _ := json.Marshal(map[string]any{"alg": "none"})
+			c, _ := json.Marshal(testJWTClaims(nil))
+
+			return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c) + "."
+		}},
+	}
+
+	v := testJWTVerifier()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token(t))
+			if !errors.Is(err, grpcauth.ErrInvalidCredentials) {
+				t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+			}
+		})
+	}
+}
+
+func TestJWT_Leeway(t *testing.T) {
+	token := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"},
+		testJWTClaims(map[string]any{"exp": testJWTNow.Add(-10 * time.Second).Unix()}))
+
+	if _, err := testJWTVerifier().Verify(context.Background(), token); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+}
+
+func TestJWT_AlgorithmsRestricted(t *testing.T) {
+	v := grpcauth.NewJWTVerifier(grpcauth.JWTVerifierConfig{
+		Keys:       grpcauth.JWTKeys{"": testHMACKey},
+		Algorithms: []string{grpcauth.JWTAlgHS512},
+		Now:        func() time.Time { return testJWTNow },
+	})
+
+	token := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, nil, testJWTClaims(nil))
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, grpcauth.ErrInvalidCredentials) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+	}
+}
+
+func TestJWT_RequiredClaims(t *testing.T) {
+	v := grpcauth.NewJWTVerifier(grpcauth.JWTVerifierConfig{
+		Keys:          grpcauth.JWTKeys{"": testHMACKey},
+		RequireExpiry: true,
+		Types:         []string{"at+jwt"},
+		Now:           func() time.Time { return testJWTNow },
+	})
+
+	tests := []struct {
+		name     string
+		header   map[string]any
+		claims   map[string]any
+		expected error
+	}{
+		{"access token", map[string]any{"typ": "at+jwt"}, testJWTClaims(nil), nil},
+		{"media type", map[string]any{"typ": "application/AT+JWT"}, testJWTClaims(nil), nil},
+		{"id token", map[string]any{"typ": "JWT"}, testJWTClaims(nil), grpcauth.ErrInvalidCredentials},
+		{"missing type", map[string]any{"typ": nil}, testJWTClaims(nil), grpcauth.ErrInvalidCredentials},
+		{"missing expiry", map[string]any{"typ": "at+jwt"}, testJWTClaims(map[string]any{"exp": nil}),
+			grpcauth.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, tt.header, tt.claims)
+			if _, err := v.Verify(context.Background(), token); !errors.Is(err, tt.expected) {
+				t.Errorf("expected error to be '%v', returned '%v'", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestJWT_VerifyAuthorization(t *testing.T) {
+	token := signTestJWT(t, grpcauth.JWTAlgEdDSA, testEdKey, map[string]any{"kid": "ed"},
+		testJWTClaims(map[string]any{"tenant": "acme"}))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Bearer " + token},
+	})
+
+	authCtx, err := grpcauth.VerifyAuthenticatorsFunc(
+		grpcauth.NewBearerErrAuthenticator(testJWTVerifier().VerifyBearer),
+	)(ctx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "jwt-user" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "jwt-user", v)
+	}
+
+	if v := authCtx.Value(grpcauth.Online); v != false {
+		t.Errorf("expected context value grpcauth.Online to be '%t', received '%t'", false, v)
+	}
+
+	p, _ := grpcauth.PrincipalFromContext(authCtx)
+	if !p.HasScope("write") || !p.HasRole("admin") {
+		t.Errorf("expected principal to have scope 'write' and role 'admin', received '%v' '%v'", p.Scopes, p.Roles)
+	}
+
+	if v, _ := p.Claim("tenant"); v != "acme" {
+		t.Errorf("expected principal claim 'tenant' to be '%s', received '%v'", "acme", v)
+	}
+}
@@ -0,0 +1,160 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"slices"
+	"strings"
+)
+
+const (
+	base62Alphabet       = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	apiKeyRandomLength   = 32
+	apiKeyChecksumLength = 6
+)
+
+var (
+	// ErrAPIKeyMalformed is returned when an API key is not in the "<prefix>_<random>_<checksum>" format.
+	ErrAPIKeyMalformed = errors.New("malformed API key")
+
+	// ErrAPIKeyChecksum is returned when the checksum of an API key does not match.
+	ErrAPIKeyChecksum = errors.New("API key checksum mismatch")
+)
+
+// ParsedAPIKey is an API key split into its parts.
+type ParsedAPIKey struct {
+	// Prefix identifies the kind of key, it may contain underscores.
+	Prefix string
+
+	// Random is the secret part of the key, 32 base62 characters.
+	Random string
+
+	// Checksum is the base62 encoded CRC32 of the prefix and random parts, 6 characters.
+	Checksum string
+}
+
+// GenerateAPIKey returns a new API key in the "<prefix>_<random>_<checksum>" format.
+//
+// The prefix must be made of ASCII letters, digits and underscores, and must not start or end with an underscore.
+// A recognisable prefix lets secret scanners find leaked keys, the checksum lets malformed keys be rejected without
+// a lookup.
+func GenerateAPIKey(prefix string) (string, error) {
+	if !validAPIKeyPrefix(prefix) {
+		return "", fmt.Errorf("%w: invalid prefix '%s'", ErrAPIKeyMalformed, prefix)
+	}
+
+	random, err := randomBase62(apiKeyRandomLength)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + "_" + random + "_" + apiKeyChecksum(prefix, random), nil
+}
+
+// ParseAPIKey splits the API key into its parts and verifies the checksum.
+func ParseAPIKey(key string) (ParsedAPIKey, error) {
+	rest, checksum, ok := cutLast(key, "_")
+	if !ok {
+		return ParsedAPIKey{}, ErrAPIKeyMalformed
+	}
+
+	prefix, random, ok := cutLast(rest, "_")
+	if !ok || !validAPIKeyPrefix(prefix) || len(random) != apiKeyRandomLength || !isBase62(random) ||
+		len(checksum) != apiKeyChecksumLength || !isBase62(checksum) {
+		return ParsedAPIKey{}, ErrAPIKeyMalformed
+	}
+
+	if apiKeyChecksum(prefix, random) != checksum {
+		return ParsedAPIKey{}, ErrAPIKeyChecksum
+	}
+
+	return ParsedAPIKey{Prefix: prefix, Random: random, Checksum: checksum}, nil
+}
+
+// ValidateAPIKeyFunc wraps the bearer verification function so tokens that are malformed, have a bad checksum or,
+// when prefixes are given, have a different prefix are rejected as ErrInvalidCredentials without calling it.
+func ValidateAPIKeyFunc(verify AuthVerifyBearerErrFunc, prefixes ...string) AuthVerifyBearerErrFunc {
+	return func(ctx context.Context, token string) (context.Context, string, bool, error) {
+		parsed, err := ParseAPIKey(token)
+		if err != nil {
+			return ctx, "", false, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+		}
+
+		if len(prefixes) > 0 && !slices.Contains(prefixes, parsed.Prefix) {
+			return ctx, "", false, fmt.Errorf("%w: unexpected API key prefix", ErrInvalidCredentials)
+		}
+
+		return verify(ctx, token)
+	}
+}
+
+func apiKeyChecksum(prefix, random string) string {
+	sum := crc32.ChecksumIEEE([]byte(prefix + "_" + random))
+
+	out := make([]byte, apiKeyChecksumLength)
+	for i := apiKeyChecksumLength - 1; i >= 0; i-- {
+		out[i] = base62Alphabet[sum%uint32(len(base62Alphabet))]
+		sum /= uint32(len(base62Alphabet))
+	}
+
+	return string(out)
+}
+
+// randomBase62 returns n uniformly distributed base62 characters.
+func randomBase62(n int) (string, error) {
+	// bytes at or above the largest multiple of 62 are discarded to avoid modulo bias.
+	const limit = 256 - 256%len(base62Alphabet)
+
+	out := make([]byte, 0, n)
+	buf := make([]byte, n)
+
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("unable to generate API key: %w", err)
+		}
+
+		for _, b := range buf {
+			if int(b) < limit && len(out) < n {
+				out = append(out, base62Alphabet[int(b)%len(base62Alphabet)])
+			}
+		}
+	}
+
+	return string(out), nil
+}
+
+func validAPIKeyPrefix(prefix string) bool {
+	if prefix == "" || strings.HasPrefix(prefix, "_") || strings.HasSuffix(prefix, "_") {
+		return false
+	}
+
+	for _, c := range prefix {
+		if c != '_' && !strings.ContainsRune(base62Alphabet, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isBase62(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune(base62Alphabet, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
@@ -0,0 +1,100 @@
+package grpcauth_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+)
+
+const testFormattedAPIKey = "pk_live_0123456789abcdefghijABCDEFGHIJxy_0Mslnz"
+
+func TestGenerateAPIKey(t *testing.T) {
+	expected := regexp.MustCompile(`^pk_live_[0-9A-Za-z]{32}_[0-9A-Za-z]{6}$`)
+
+	seen := map[string]bool{}
+
+	for range 100 {
+		key, err := grpcauth.GenerateAPIKey("pk_live")
+		if err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+
+		if !expected.MatchString(key) {
+			t.Errorf("expected key to match '%s', received '%s'", expected, key)
+		}
+
+		if seen[key] {
+			t.Errorf("expected key '%s' to be unique", key)
+		}
+
+		seen[key] = true
+
+		if _, err := grpcauth.ParseAPIKey(key); err != nil {
+			t.Errorf("expected error to be nil, returned '%v'", err)
+		}
+	}
+}
+
+func TestGenerateAPIKey_InvalidPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "_pk", "pk_", "pk-live", "pk live"} {
+		if _, err := grpcauth.GenerateAPIKey(prefix); !errors.Is(err, grpcauth.ErrAPIKeyMalformed) {
+			t.Errorf("expected prefix '%s' error to be '%v', returned '%v'", prefix, grpcauth.ErrAPIKeyMalformed, err)
+		}
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		name, key   string
+		expectedErr error
+	}{
+		{"valid", testFormattedAPIKey, nil},
+		{"bad checksum", "pk_live_0123456789abcdefghijABCDEFGHIJxy_0Mslny", grpcauth.ErrAPIKeyChecksum},
+		{"typo in random", "pk_live_0123456789abcdefghijABCDEFGHIJxz_0Mslnz", grpcauth.ErrAPIKeyChecksum},
+		{"no separators", "pklive0123456789abcdefghijABCDEFGHIJxy0Mslnz", grpcauth.ErrAPIKeyMalformed},
+		{"short random", "pk_live_0123456789_0Mslnz", grpcauth.ErrAPIKeyMalformed},
+		{"invalid character", "pk_live_0123456789abcdefghijABCDEFGHIJx-_0Mslnz", grpcauth.ErrAPIKeyMalformed},
+		{"missing prefix", "_0123456789abcdefghijABCDEFGHIJxy_0Mslnz", grpcauth.ErrAPIKeyMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := grpcauth.ParseAPIKey(tt.key)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error to be '%v', returned '%v'", tt.expectedErr, err)
+			}
+
+			if tt.expectedErr == nil && (parsed.Prefix != "pk_live" || parsed.Checksum != "0Mslnz") {
+				t.Errorf("expected prefix 'pk_live' and checksum '0Mslnz', received '%+v'", parsed)
+			}
+		})
+	}
+}
+
+func TestValidateAPIKeyFunc(t *testing.T) {
+	verifier := &countingBearerVerifier{}
+	verify := grpcauth.ValidateAPIKeyFunc(verifier.VerifyBearer, "pk_live", "pk_test")
+
+	if _, user, _, err := verify(context.Background(), testFormattedAPIKey); err != nil ||
+		user != "cached-"+testFormattedAPIKey {
+		t.Errorf("expected key to be verified, received '%s' '%v'", user, err)
+	}
+
+	otherPrefix, err := grpcauth.GenerateAPIKey("sk_live")
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	for _, token := range []string{"pk_live_0123456789abcdefghijABCDEFGHIJxy_0Mslny", "not-a-key", otherPrefix} {
+		if _, _, _, err := verify(context.Background(), token); !errors.Is(err, grpcauth.ErrInvalidCredentials) {
+			t.Errorf("expected '%s' error to be '%v', returned '%v'", token, grpcauth.ErrInvalidCredentials, err)
+		}
+	}
+
+	if c := verifier.calls.Load(); c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+}
@@ -0,0 +1,268 @@
+package grpcauth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// OwnershipPolicy checks request fields annotated with the (grpcauth.owner) option match the authenticated
+// principal before the handler is called, it is evaluated after authentication.
+//
+//	message GetAccountRequest {
+//	    string account_id = 1 [(grpcauth.owner) = { claim: "accounts" }];
+//	}
+//
+// The field matches when it is equal to the claim, or any of the values when the claim is a list. Fields without a
+// claim are matched against the subject. Annotated fields must be set, repeated fields must be non-empty and all of
+// the values must match, annotated fields of nested messages, repeated messages and map values that are set are
+// also checked. Requests that do not match are rejected with codes.PermissionDenied.
+type OwnershipPolicy struct {
+	owned *annotatedMessages
+}
+
+// NewOwnershipPolicy returns a new OwnershipPolicy.
+func NewOwnershipPolicy() *OwnershipPolicy {
+	return &OwnershipPolicy{
+		owned: &annotatedMessages{annotated: func(fd protoreflect.FieldDescriptor) bool {
+			_, ok := fieldOwner(fd)
+
+			return ok
+		}},
+	}
+}
+
+// Authorize checks the annotated fields of the request match the principal from the context, requests that are
+// not protobuf messages or without annotated fields are allowed.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func (p *OwnershipPolicy) Authorize(ctx context.Context, req any) error {
+	msg, ok := req.(proto.Message)
+	if !ok || !p.owned.has(msg.ProtoReflect().Descriptor()) {
+		return nil
+	}
+
+	pr, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	if fd := ownershipMismatch(pr, msg.ProtoReflect()); fd != nil {
+		return status.Errorf(codes.PermissionDenied, "request field '%s' does not match the principal", fd.FullName())
+	}
+
+	return nil
+}
+
+// UnaryServerInterceptor returns an interceptor that checks unary requests, it must be installed after the
+// authentication interceptor.
+func (p *OwnershipPolicy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := p.Authorize(ctx, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that checks each message received on a stream, it must be
+// installed after the authentication interceptor.
+func (p *OwnershipPolicy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &ownershipServerStream{ServerStream: ss, policy: p})
+	}
+}
+
+// annotatedMessages caches if messages have fields with an option.
+type annotatedMessages struct {
+	annotated func(fd protoreflect.FieldDescriptor) bool
+	messages  sync.Map // message full name -> bool
+}
+
+// has returns true if the message or any message it contains has annotated fields.
+func (a *annotatedMessages) has(md protoreflect.MessageDescriptor) bool {
+	if v, ok := a.messages.Load(md.FullName()); ok {
+		annotated, _ := v.(bool)
+
+		return annotated
+	}
+
+	annotated := a.search(md, map[protoreflect.FullName]bool{})
+	a.messages.Store(md.FullName(), annotated)
+
+	return annotated
+}
+
+// search returns true if the message or any message it contains has annotated fields, seen prevents recursive
+// messages from being visited more than once.
+func (a *annotatedMessages) search(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) bool {
+	if seen[md.FullName()] {
+		return false
+	}
+
+	seen[md.FullName()] = true
+
+	fields := md.Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if a.annotated(fd) {
+			return true
+		}
+
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+
+		if fd.Message() != nil && a.search(fd.Message(), seen) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ownershipMismatch returns the first annotated field of the message that does not match the principal.
+func ownershipMismatch(pr *Principal, m protoreflect.Message) protoreflect.FieldDescriptor {
+	fields := m.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+
+		if owner, ok := fieldOwner(fd); ok {
+			if !m.Has(fd) || !ownerMatches(ownerValues(pr, owner), fd, m.Get(fd)) {
+				return fd
+			}
+
+			continue
+		}
+
+		if !m.Has(fd) {
+			continue
+		}
+
+		var mismatch protoreflect.FieldDescriptor
+
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				continue
+			}
+
+			m.Get(fd).Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				mismatch = ownershipMismatch(pr, v.Message())
+
+				return mismatch == nil
+			})
+		case fd.IsList() && fd.Message() != nil:
+			list := m.Get(fd).List()
+			for j := 0; j < list.Len() && mismatch == nil; j++ {
+				mismatch = ownershipMismatch(pr, list.Get(j).Message())
+			}
+		case fd.Message() != nil:
+			mismatch = ownershipMismatch(pr, m.Get(fd).Message())
+		}
+
+		if mismatch != nil {
+			return mismatch
+		}
+	}
+
+	return nil
+}
+
+// fieldOwner returns the (grpcauth.owner) option of the field and if it was present.
+func fieldOwner(fd protoreflect.FieldDescriptor) (*Owner, bool) {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || !proto.HasExtension(opts, E_Owner) {
+		return nil, false
+	}
+
+	owner, ok := proto.GetExtension(opts, E_Owner).(*Owner)
+
+	return owner, ok
+}
+
+// ownerValues returns the values of the principal the field may match.
+func ownerValues(pr *Principal, owner *Owner) []string {
+	if owner.GetClaim() == "" {
+		return []string{pr.Subject}
+	}
+
+	v, ok := pr.Claim(owner.GetClaim())
+	if !ok || v == nil {
+		return nil
+	}
+
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+
+		return values
+	}
+
+	return []string{fmt.Sprint(v)}
+}
+
+// ownerMatches returns true if the field value is one of the owner values, all of the values of repeated fields
+// must match.
+func ownerMatches(values []string, fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+	switch {
+	case fd.IsMap() || fd.Message() != nil:
+		return false
+	case fd.IsList():
+		list := v.List()
+		for i := range list.Len() {
+			if !slices.Contains(values, ownerFieldString(fd, list.Get(i))) {
+				return false
+			}
+		}
+
+		return list.Len() > 0
+	}
+
+	return slices.Contains(values, ownerFieldString(fd, v))
+}
+
+// ownerFieldString returns the scalar field value as a string, enums are returned by name.
+func ownerFieldString(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() { //nolint:exhaustive // other kinds are formatted by String.
+	case protoreflect.BytesKind:
+		return string(v.Bytes())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+	}
+
+	return v.String()
+}
+
+// ownershipServerStream checks each message received on the stream.
+type ownershipServerStream struct {
+	grpc.ServerStream
+
+	policy *OwnershipPolicy
+}
+
+func (s *ownershipServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err //nolint:wrapcheck // stream errors are returned unchanged.
+	}
+
+	return s.policy.Authorize(s.Context(), m)
+}
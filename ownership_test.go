@@ -0,0 +1,164 @@
+package grpcauth_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"github.com/dosquad/go-grpcauth/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOwnershipPolicy_Authorize(t *testing.T) {
+	ctx := grpcauth.NewContextWithPrincipal(context.Background(), &grpcauth.Principal{
+		Subject: "alice",
+		Claims: map[string]any{
+			"tenant":   "t1",
+			"accounts": []any{"a1", "a2"},
+		},
+	})
+
+	owned := func(fn func(req *test.OwnedRequest)) *test.OwnedRequest {
+		req := &test.OwnedRequest{User: "alice", Tenants: []string{"t1"}}
+		if fn != nil {
+			fn(req)
+		}
+
+		return req
+	}
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		req          any
+		expectedCode codes.Code
+	}{
+		{"owned", ctx, owned(nil), codes.OK},
+		{"subject mismatch", ctx, owned(func(r *test.OwnedRequest) { r.User = "bob" }), codes.PermissionDenied},
+		{"subject not set", ctx, owned(func(r *test.OwnedRequest) { r.User = "" }), codes.PermissionDenied},
+		{
+			"repeated mismatch",
+			ctx,
+			owned(func(r *test.OwnedRequest) { r.Tenants = []string{"t1", "t2"} }),
+			codes.PermissionDenied,
+		},
+		{"repeated empty", ctx, owned(func(r *test.OwnedRequest) { r.Tenants = nil }), codes.PermissionDenied},
+		{"nested claim list", ctx, owned(func(r *test.OwnedRequest) { r.Account = &test.Account{Id: "a2"} }), codes.OK},
+		{
+			"nested mismatch",
+			ctx,
+			owned(func(r *test.OwnedRequest) { r.Account = &test.Account{Id: "a3"} }),
+			codes.PermissionDenied,
+		},
+		{
+			"nested not set",
+			ctx,
+			owned(func(r *test.OwnedRequest) { r.Account = &test.Account{Name: "a1"} }),
+			codes.PermissionDenied,
+		},
+		{
+			"repeated messages",
+			ctx,
+			owned(func(r *test.OwnedRequest) { r.Accounts = []*test.Account{{Id: "a1"}, {Id: "a3"}} }),
+			codes.PermissionDenied,
+		},
+		{
+			"map values",
+			ctx,
+			owned(func(r *test.OwnedRequest) { r.AccountsByName = map[string]*test.Account{"x": {Id: "a1"}} }),
+			codes.OK,
+		},
+		{
+			"map values mismatch",
+			ctx,
+			owned(func(r *test.OwnedRequest) { r.AccountsByName = map[string]*test.Account{"x": {Id: "a3"}} }),
+			codes.PermissionDenied,
+		},
+		{"unauthenticated", context.Background(), owned(nil), codes.Unauthenticated},
+		{"without annotations", context.Background(), &test.EmptyRequest{}, codes.OK},
+		{"not a message", context.Background(), struct{}{}, codes.OK},
+	}
+
+	p := grpcauth.NewOwnershipPolicy()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(p.Authorize(tt.ctx, tt.req)); code != tt.expectedCode {
+				t.Errorf("expected status code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
+type testRecvServerStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context //nolint:containedctx // test code
+	msgs []proto.Message
+}
+
+func (s *testRecvServerStream) Context() context.Context { return s.ctx }
+
+func (s *testRecvServerStream) RecvMsg(m any) error {
+	if len(s.msgs) == 0 {
+		return io.EOF
+	}
+
+	proto.Merge(m.(proto.Message), s.msgs[0]) //nolint:forcetypeassert // test code
+	s.msgs = s.msgs[1:]
+
+	return nil
+}
+
+func TestOwnershipPolicy_Interceptors(t *testing.T) {
+	p := grpcauth.NewOwnershipPolicy()
+	ctx := grpcauth.NewContextWithPrincipal(context.Background(), &grpcauth.Principal{
+		Subject: "alice",
+		Claims:  map[string]any{"tenant": "t1"},
+	})
+
+	called := false
+
+	_, err := p.UnaryServerInterceptor()(ctx, &test.OwnedRequest{User: "bob", Tenants: []string{"t1"}},
+		&grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+			called = true
+
+			return struct{}{}, nil
+		})
+	if status.Code(err) != codes.PermissionDenied || called {
+		t.Errorf("expected unary status code to be '%s', received '%s'", codes.PermissionDenied, status.Code(err))
+	}
+
+	ss := &testRecvServerStream{ctx: ctx, msgs: []proto.Message{
+		&test.OwnedRequest{User: "alice", Tenants: []string{"t1"}},
+		&test.OwnedRequest{User: "bob", Tenants: []string{"t1"}},
+	}}
+
+	received := 0
+
+	err = p.StreamServerInterceptor()(nil, ss, &grpc.StreamServerInfo{}, func(_ any, ss grpc.ServerStream) error {
+		for {
+			if err := ss.RecvMsg(&test.OwnedRequest{}); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+
+				return err
+			}
+
+			received++
+		}
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected stream status code to be '%s', received '%s'", codes.PermissionDenied, status.Code(err))
+	}
+
+	if received != 1 {
+		t.Errorf("expected 1 message to be received, received '%d'", received)
+	}
+}
@@ -0,0 +1,505 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Password hash algorithms supported by HashPassword and VerifyPassword.
+const (
+	PasswordArgon2id     = "argon2id"
+	PasswordScrypt       = "scrypt"
+	PasswordPBKDF2SHA256 = "pbkdf2-sha256"
+	PasswordPBKDF2SHA512 = "pbkdf2-sha512"
+	PasswordBcrypt       = "bcrypt"
+)
+
+const (
+	defaultArgon2Memory           = 19456
+	defaultArgon2Time             = 2
+	defaultArgon2Threads          = 1
+	defaultScryptLogN             = 17
+	defaultScryptR                = 8
+	defaultScryptP                = 1
+	defaultPBKDF2SHA256Iterations = 600000
+	defaultPBKDF2SHA512Iterations = 210000
+	defaultBcryptCost             = 10
+	passwordSaltLength            = 16
+	passwordKeyLength             = 32
+	argon2Version                 = 19
+)
+
+var (
+	// ErrPasswordHashInvalid is returned when a password hash is malformed or uses an unsupported algorithm.
+	ErrPasswordHashInvalid = errors.New("invalid password hash")
+
+	// ErrPasswordNotFound is returned by a PasswordStore when the user does not exist.
+	ErrPasswordNotFound = errors.New("password not found")
+)
+
+// PasswordHashPolicy is the algorithm and minimum parameters passwords should be hashed with, zero values use the
+// OWASP recommended defaults.
+type PasswordHashPolicy struct {
+	// Algorithm is the algorithm new hashes are created with, defaults to PasswordArgon2id.
+	Algorithm string
+
+	// Argon2Memory is the argon2id memory in KiB, defaults to 19456.
+	Argon2Memory uint32
+
+	// Argon2Time is the argon2id number of passes, defaults to 2.
+	Argon2Time uint32
+
+	// Argon2Threads is the argon2id degree of parallelism, defaults to 1.
+	Argon2Threads uint8
+
+	// ScryptLogN is the base 2 logarithm of the scrypt CPU/memory cost, defaults to 17.
+	ScryptLogN uint8
+
+	// ScryptR is the scrypt block size, defaults to 8.
+	ScryptR int
+
+	// ScryptP is the scrypt parallelism, defaults to 1.
+	ScryptP int
+
+	// PBKDF2Iterations is the PBKDF2 iteration count, defaults to 600000 for SHA-256 and 210000 for SHA-512.
+	PBKDF2Iterations int
+
+	// BcryptCost is the bcrypt cost, defaults to 10.
+	BcryptCost int
+}
+
+func (p PasswordHashPolicy) withDefaults() PasswordHashPolicy {
+	if p.Algorithm == "" {
+		p.Algorithm = PasswordArgon2id
+	}
+
+	if p.Argon2Memory == 0 {
+		p.Argon2Memory = defaultArgon2Memory
+	}
+
+	if p.Argon2Time == 0 {
+		p.Argon2Time = defaultArgon2Time
+	}
+
+	if p.Argon2Threads == 0 {
+		p.Argon2Threads = defaultArgon2Threads
+	}
+
+	if p.ScryptLogN == 0 {
+		p.ScryptLogN = defaultScryptLogN
+	}
+
+	if p.ScryptR == 0 {
+		p.ScryptR = defaultScryptR
+	}
+
+	if p.ScryptP == 0 {
+		p.ScryptP = defaultScryptP
+	}
+
+	if p.BcryptCost == 0 {
+		p.BcryptCost = defaultBcryptCost
+	}
+
+	return p
+}
+
+func (p PasswordHashPolicy) pbkdf2Iterations(alg string) int {
+	switch {
+	case p.PBKDF2Iterations > 0:
+		return p.PBKDF2Iterations
+	case alg == PasswordPBKDF2SHA512:
+		return defaultPBKDF2SHA512Iterations
+	}
+
+	return defaultPBKDF2SHA256Iterations
+}
+
+// HashPassword returns the password hashed with the policy algorithm and parameters.
+//
+// Hashes are encoded in the PHC string format, except bcrypt which uses its own "$2a$" format.
+func HashPassword(password string, policy PasswordHashPolicy) (string, error) {
+	policy = policy.withDefaults()
+
+	if policy.Algorithm == PasswordBcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), policy.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("unable to hash password: %w", err)
+		}
+
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("unable to generate salt: %w", err)
+	}
+
+	var params string
+
+	switch policy.Algorithm {
+	case PasswordArgon2id:
+		params = fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", argon2Version, policy.Argon2Memory, policy.Argon2Time,
+			policy.Argon2Threads)
+	case PasswordScrypt:
+		params = fmt.Sprintf("ln=%d,r=%d,p=%d", policy.ScryptLogN, policy.ScryptR, policy.ScryptP)
+	case PasswordPBKDF2SHA256, PasswordPBKDF2SHA512:
+		params = "i=" + strconv.Itoa(policy.pbkdf2Iterations(policy.Algorithm))
+	default:
+		return "", fmt.Errorf("%w: unsupported algorithm '%s'", ErrPasswordHashInvalid, policy.Algorithm)
+	}
+
+	ph, err := parsePHC("$" + policy.Algorithm + "$" + params + "$" + base64.RawStdEncoding.EncodeToString(salt))
+	if err != nil {
+		return "", err
+	}
+
+	key, err := ph.derive(password, passwordKeyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return ph.encoded + "$" + base64.RawStdEncoding.EncodeToString(key), nil
+}
+
+// VerifyPassword compares the password against the hash in constant time, needsRehash is true when the hash does
+// not use the policy algorithm or its parameters are weaker than the policy.
+//
+// PHC formatted argon2id, scrypt, pbkdf2-sha256 and pbkdf2-sha512 hashes are supported, along with bcrypt hashes.
+func VerifyPassword(hashed, password string, policy PasswordHashPolicy) (bool, bool, error) {
+	policy = policy.withDefaults()
+
+	if strings.HasPrefix(hashed, "$2") {
+		if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+
+			return false, false, fmt.Errorf("%w: %w", ErrPasswordHashInvalid, err)
+		}
+
+		cost, _ := bcrypt.Cost([]byte(hashed))
+
+		return true, policy.Algorithm != PasswordBcrypt || cost < policy.BcryptCost, nil
+	}
+
+	ph, err := parsePHC(hashed)
+	if err != nil {
+		return false, false, err
+	}
+
+	if len(ph.hash) == 0 {
+		return false, false, fmt.Errorf("%w: missing hash", ErrPasswordHashInvalid)
+	}
+
+	key, err := ph.derive(password, len(ph.hash))
+	if err != nil {
+		return false, false, err
+	}
+
+	if subtle.ConstantTimeCompare(key, ph.hash) != 1 {
+		return false, false, nil
+	}
+
+	return true, ph.needsRehash(policy), nil
+}
+
+// phcHash is a parsed PHC string, "$<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]".
+type phcHash struct {
+	encoded string
+	alg     string
+	params  map[string]int
+	salt    []byte
+	hash    []byte
+}
+
+func parsePHC(hashed string) (*phcHash, error) {
+	fields := strings.Split(hashed, "$")
+	if len(fields) < 3 || fields[0] != "" || fields[1] == "" {
+		return nil, fmt.Errorf("%w: not a PHC string", ErrPasswordHashInvalid)
+	}
+
+	ph := &phcHash{alg: fields[1], params: map[string]int{}}
+	fields = fields[2:]
+
+	for len(fields) > 0 && strings.Contains(fields[0], "=") {
+		for param := range strings.SplitSeq(fields[0], ",") {
+			name, value, _ := strings.Cut(param, "=")
+
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%w: invalid parameter '%s'", ErrPasswordHashInvalid, param)
+			}
+
+			ph.params[name] = n
+		}
+
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("%w: missing salt", ErrPasswordHashInvalid)
+	}
+
+	var err error
+	if ph.salt, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(fields[0], "=")); err != nil {
+		return nil, fmt.Errorf("%w: invalid salt encoding", ErrPasswordHashInvalid)
+	}
+
+	ph.encoded = hashed
+
+	if len(fields) == 2 {
+		ph.encoded = strings.TrimSuffix(hashed, "$"+fields[1])
+
+		if ph.hash, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(fields[1], "=")); err != nil {
+			return nil, fmt.Errorf("%w: invalid hash encoding", ErrPasswordHashInvalid)
+		}
+	}
+
+	return ph, nil
+}
+
+func (ph *phcHash) param(name string) (int, error) {
+	n, ok := ph.params[name]
+	if !ok || n == 0 {
+		return 0, fmt.Errorf("%w: missing parameter '%s'", ErrPasswordHashInvalid, name)
+	}
+
+	return n, nil
+}
+
+//nolint:gosec // parameters are bounds checked before conversion.
+func (ph *phcHash) derive(password string, keyLen int) ([]byte, error) {
+	switch ph.alg {
+	case PasswordArgon2id:
+		if v, ok := ph.params["v"]; ok && v != argon2Version {
+			return nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrPasswordHashInvalid, v)
+		}
+
+		m, t, p, err := ph.argon2Params()
+		if err != nil {
+			return nil, err
+		}
+
+		return argon2.IDKey([]byte(password), ph.salt, t, m, p, uint32(keyLen)), nil
+	case PasswordScrypt:
+		ln, r, p, err := ph.scryptParams()
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := scrypt.Key([]byte(password), ph.salt, 1<<ln, r, p, keyLen)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrPasswordHashInvalid, err)
+		}
+
+		return key, nil
+	case PasswordPBKDF2SHA256, PasswordPBKDF2SHA512:
+		iter, err := ph.param("i")
+		if err != nil {
+			return nil, err
+		}
+
+		newHash := func() hash.Hash { return sha256.New() }
+		if ph.alg == PasswordPBKDF2SHA512 {
+			newHash = func() hash.Hash { return sha512.New() }
+		}
+
+		key, err := pbkdf2.Key(newHash, password, ph.salt, iter, keyLen)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrPasswordHashInvalid, err)
+		}
+
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported algorithm '%s'", ErrPasswordHashInvalid, ph.alg)
+}
+
+//nolint:gosec,mnd // parameters are bounds checked before conversion.
+func (ph *phcHash) argon2Params() (uint32, uint32, uint8, error) {
+	m, err := ph.param("m")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	t, err := ph.param("t")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	p, err := ph.param("p")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if m > 1<<32-1 || t > 1<<32-1 || p > 255 {
+		return 0, 0, 0, fmt.Errorf("%w: argon2 parameters out of range", ErrPasswordHashInvalid)
+	}
+
+	return uint32(m), uint32(t), uint8(p), nil
+}
+
+//nolint:mnd // parameters are bounds checked before use.
+func (ph *phcHash) scryptParams() (int, int, int, error) {
+	ln, err := ph.param("ln")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	r, err := ph.param("r")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	p, err := ph.param("p")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if ln > 62 {
+		return 0, 0, 0, fmt.Errorf("%w: scrypt parameters out of range", ErrPasswordHashInvalid)
+	}
+
+	return ln, r, p, nil
+}
+
+//nolint:gosec // parameters were validated when the hash was verified.
+func (ph *phcHash) needsRehash(policy PasswordHashPolicy) bool {
+	if ph.alg != policy.Algorithm {
+		return true
+	}
+
+	switch ph.alg {
+	case PasswordArgon2id:
+		m, t, p, _ := ph.argon2Params()
+
+		return m < policy.Argon2Memory || t < policy.Argon2Time || p < policy.Argon2Threads
+	case PasswordScrypt:
+		ln, r, p, _ := ph.scryptParams()
+
+		return ln < int(policy.ScryptLogN) || r < policy.ScryptR || p < policy.ScryptP
+	case PasswordPBKDF2SHA256, PasswordPBKDF2SHA512:
+		return ph.params["i"] < policy.pbkdf2Iterations(ph.alg)
+	}
+
+	return false
+}
+
+// PasswordStore returns the password hash for a user.
+type PasswordStore interface {
+	// PasswordHash returns the hash for the user, or ErrPasswordNotFound when the user does not exist.
+	PasswordHash(ctx context.Context, username string) (string, error)
+}
+
+// PasswordStoreFunc is a function that implements PasswordStore.
+type PasswordStoreFunc func(ctx context.Context, username string) (string, error)
+
+// PasswordHash calls the function.
+func (f PasswordStoreFunc) PasswordHash(ctx context.Context, username string) (string, error) {
+	return f(ctx, username)
+}
+
+// PasswordHashes is a static PasswordStore of password hashes keyed by username.
+type PasswordHashes map[string]string
+
+// PasswordHash returns the hash for the user.
+func (h PasswordHashes) PasswordHash(_ context.Context, username string) (string, error) {
+	if hashed, ok := h[username]; ok {
+		return hashed, nil
+	}
+
+	return "", ErrPasswordNotFound
+}
+
+// PasswordVerifierConfig is the configuration for NewPasswordVerifier.
+type PasswordVerifierConfig struct {
+	// Store returns the password hashes.
+	Store PasswordStore
+
+	// Policy is the algorithm and minimum parameters, hashes that do not satisfy it are reported to Rehash.
+	Policy PasswordHashPolicy
+
+	// Rehash is called after a successful verification with the password hashed using the policy when the stored
+	// hash needs rehashing, it is called before the verification returns.
+	Rehash func(ctx context.Context, username, hashed string)
+}
+
+// PasswordVerifier verifies Basic credentials against password hashes from a PasswordStore.
+type PasswordVerifier struct {
+	cfg       PasswordVerifierConfig
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// NewPasswordVerifier returns a new PasswordVerifier.
+func NewPasswordVerifier(cfg PasswordVerifierConfig) *PasswordVerifier {
+	return &PasswordVerifier{cfg: cfg}
+}
+
+// VerifyBasic is an AuthVerifyBasicErrFunc that checks the password against the hash from the store.
+//
+// Unknown users are compared against a dummy hash created with the policy so the response time does not reveal
+// which users exist. Store errors other than ErrPasswordNotFound are reported as ErrVerifierUnavailable.
+func (v *PasswordVerifier) VerifyBasic(ctx context.Context, u, p string) (context.Context, string, error) {
+	hashed, err := v.cfg.Store.PasswordHash(ctx, u)
+
+	switch {
+	case errors.Is(err, ErrPasswordNotFound):
+		_, _, _ = VerifyPassword(v.dummyHash(), p, v.cfg.Policy)
+
+		return ctx, "", ErrInvalidCredentials
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ctx, "", err
+	case err != nil:
+		return ctx, "", fmt.Errorf("%w: unable to retrieve password hash: %w", ErrVerifierUnavailable, err)
+	}
+
+	ok, needsRehash, err := VerifyPassword(hashed, p, v.cfg.Policy)
+	if err != nil {
+		return ctx, "", err
+	}
+
+	if !ok {
+		return ctx, "", ErrInvalidCredentials
+	}
+
+	if needsRehash && v.cfg.Rehash != nil {
+		if rehashed, err := HashPassword(p, v.cfg.Policy); err == nil {
+			v.cfg.Rehash(ctx, u, rehashed)
+		}
+	}
+
+	return ctx, u, nil
+}
+
+// AuthVerifyBasic is an AuthVerifyBasicFunc for use with VerifyAuthorizationFunc, any error is reported as a
+// failed verification.
+func (v *PasswordVerifier) AuthVerifyBasic(ctx context.Context, u, p string) (context.Context, string, bool) {
+	outCtx, user, err := v.VerifyBasic(ctx, u, p)
+
+	return outCtx, user, err == nil
+}
+
+func (v *PasswordVerifier) dummyHash() string {
+	v.dummyOnce.Do(func() {
+		v.dummy, _ = HashPassword("", v.cfg.Policy)
+	})
+
+	return v.dummy
+}
@@ -0,0 +1,210 @@
+package grpcauth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	testPBKDF2SHA256Hash = "$pbkdf2-sha256$i=1000$c2FsdHNhbHRzYWx0c2FsdA$8nX7hwFEzIB8aPajJTYK8weHQc5Ngz0pFVAKvSu4jQA"
+	testPBKDF2SHA512Hash = "$pbkdf2-sha512$i=1000$c2FsdHNhbHRzYWx0c2FsdA$715rqIr5dXOVPpBhqqsugl037zT5bWJTWYmZtIcK8hBn" +
+		"isKpwfY7kokvwjDrNHqHhF50Pb7MD6HvkJwiDQw4ww"
+)
+
+//nolint:gochecknoglobals // cheap parameters keep the tests fast.
+var testPasswordPolicy = grpcauth.PasswordHashPolicy{
+	Argon2Memory:     1024,
+	Argon2Time:       1,
+	ScryptLogN:       10,
+	PBKDF2Iterations: 1000,
+	BcryptCost:       4,
+}
+
+func TestHashPassword_RoundTrip(t *testing.T) {
+	tests := []struct {
+		algorithm, prefix string
+	}{
+		{grpcauth.PasswordArgon2id, "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{grpcauth.PasswordScrypt, "$scrypt$ln=10,r=8,p=1$"},
+		{grpcauth.PasswordPBKDF2SHA256, "$pbkdf2-sha256$i=1000$"},
+		{grpcauth.PasswordPBKDF2SHA512, "$pbkdf2-sha512$i=1000$"},
+		{grpcauth.PasswordBcrypt, "$2a$04$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			policy := testPasswordPolicy
+			policy.Algorithm = tt.algorithm
+
+			hashed, err := grpcauth.HashPassword("password", policy)
+			if err != nil {
+				t.Fatalf("expected error to be nil, returned '%v'", err)
+			}
+
+			if !strings.HasPrefix(hashed, tt.prefix) {
+				t.Errorf("expected hash to start with '%s', received '%s'", tt.prefix, hashed)
+			}
+
+			ok, needsRehash, err := grpcauth.VerifyPassword(hashed, "password", policy)
+			if err != nil || !ok || needsRehash {
+				t.Errorf("expected password to verify without rehash, received '%t' '%t' '%v'", ok, needsRehash, err)
+			}
+
+			if ok, _, err := grpcauth.VerifyPassword(hashed, "passwort", policy); err != nil || ok {
+				t.Errorf("expected wrong password to fail, received '%t' '%v'", ok, err)
+			}
+		})
+	}
+}
+
+func TestVerifyPassword_KnownHashes(t *testing.T) {
+	for _, hashed := range []string{testPBKDF2SHA256Hash, testPBKDF2SHA512Hash} {
+		policy := testPasswordPolicy
+		policy.Algorithm = strings.Split(hashed, "$")[1]
+
+		ok, needsRehash, err := grpcauth.VerifyPassword(hashed, "password", policy)
+		if err != nil || !ok || needsRehash {
+			t.Errorf("expected '%s' to verify without rehash, received '%t' '%t' '%v'", hashed, ok, needsRehash, err)
+		}
+	}
+}
+
+func TestVerifyPassword_NeedsRehash(t *testing.T) {
+	weak, err := grpcauth.HashPassword("password", testPasswordPolicy)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	tests := []struct {
+		name   string
+		hashed string
+		policy grpcauth.PasswordHashPolicy
+	}{
+		{"stronger memory", weak, grpcauth.PasswordHashPolicy{Argon2Memory: 2048, Argon2Time: 1}},
+		{"stronger time", weak, grpcauth.PasswordHashPolicy{Argon2Memory: 1024, Argon2Time: 3}},
+		{"different algorithm", weak, grpcauth.PasswordHashPolicy{Algorithm: grpcauth.PasswordScrypt}},
+		{"more iterations", testPBKDF2SHA256Hash, grpcauth.PasswordHashPolicy{Algorithm: grpcauth.PasswordPBKDF2SHA256}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := grpcauth.VerifyPassword(tt.hashed, "password", tt.policy)
+			if err != nil || !ok || !needsRehash {
+				t.Errorf("expected password to verify with rehash, received '%t' '%t' '%v'", ok, needsRehash, err)
+			}
+		})
+	}
+}
+
+func TestVerifyPassword_Invalid(t *testing.T) {
+	tests := []string{
+		"password",
+		"$unknown$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1$c2FsdA$aGFzaA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1000$c2FsdA",
+		"$pbkdf2-sha256$i=abc$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1000$!!!$aGFzaA",
+		"$2a$04$invalid",
+	}
+
+	for _, hashed := range tests {
+		if _, _, err := grpcauth.VerifyPassword(hashed, "password", testPasswordPolicy); !errors.Is(
+			err,
+			grpcauth.ErrPasswordHashInvalid,
+		) {
+			t.Errorf("expected '%s' error to be '%v', returned '%v'", hashed, grpcauth.ErrPasswordHashInvalid, err)
+		}
+	}
+}
+
+func TestPasswordVerifier_VerifyBasic(t *testing.T) {
+	var rehashed map[string]string
+
+	v := grpcauth.NewPasswordVerifier(grpcauth.PasswordVerifierConfig{
+		Store: grpcauth.PasswordHashes{
+			"pbkdf2-user": testPBKDF2SHA256Hash,
+		},
+		Policy: testPasswordPolicy,
+		Rehash: func(_ context.Context, username, hashed string) {
+			rehashed[username] = hashed
+		},
+	})
+
+	tests := []struct {
+		name, user, pass string
+		expectedErr      error
+		expectedRehash   bool
+	}{
+		{"valid rehash", "pbkdf2-user", "password", nil, true},
+		{"wrong password", "pbkdf2-user", "passwort", grpcauth.ErrInvalidCredentials, false},
+		{"unknown user", "unknown-user", "password", grpcauth.ErrInvalidCredentials, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rehashed = map[string]string{}
+
+			_, user, err := v.VerifyBasic(context.Background(), tt.user, tt.pass)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error to be '%v', returned '%v'", tt.expectedErr, err)
+			}
+
+			if tt.expectedErr == nil && user != tt.user {
+				t.Errorf("expected user to be '%s', received '%s'", tt.user, user)
+			}
+
+			hashed, ok := rehashed[tt.user]
+			if ok != tt.expectedRehash {
+				t.Errorf("expected rehash to be '%t', received '%t'", tt.expectedRehash, ok)
+			}
+
+			if ok && !strings.HasPrefix(hashed, "$argon2id$") {
+				t.Errorf("expected rehash to use argon2id, received '%s'", hashed)
+			}
+		})
+	}
+}
+
+func TestPasswordVerifier_StoreUnavailable(t *testing.T) {
+	v := grpcauth.NewPasswordVerifier(grpcauth.PasswordVerifierConfig{
+		Store: grpcauth.PasswordStoreFunc(func(context.Context, string) (string, error) {
+			return "", errors.New("connection refused")
+		}),
+	})
+
+	if _, _, err := v.VerifyBasic(context.Background(), "user", "password"); !errors.Is(
+		err,
+		grpcauth.ErrVerifierUnavailable,
+	) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrVerifierUnavailable, err)
+	}
+}
+
+func TestPasswordVerifier_VerifyAuthorizationFunc(t *testing.T) {
+	v := grpcauth.NewPasswordVerifier(grpcauth.PasswordVerifierConfig{
+		Store:  grpcauth.PasswordHashes{"pbkdf2-user": testPBKDF2SHA256Hash},
+		Policy: testPasswordPolicy,
+	})
+
+	authFunc := grpcauth.VerifyAuthorizationFunc(v.AuthVerifyBasic, nil)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Basic " + base64.StdEncoding.EncodeToString([]byte("pbkdf2-user:password"))},
+	})
+
+	authCtx, err := authFunc(ctx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if v := authCtx.Value(grpcauth.Username); v != "pbkdf2-user" {
+		t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", "pbkdf2-user", v)
+	}
+}
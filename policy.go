@@ -0,0 +1,219 @@
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthMode describes the authentication required to call a method.
+type AuthMode int
+
+// unauthenticatedModeKey marks requests that the authentication mode allowed through without a principal.
+const unauthenticatedModeKey contextValue = "unauthenticated-mode"
+
+const (
+	// AuthRequired requires the request to be authenticated.
+	AuthRequired AuthMode = iota
+
+	// AuthOptional authenticates the request when credentials are present, requests where the authentication
+	// function returns ErrCredentialsMissing are allowed through unauthenticated.
+	//
+	// Requests allowed through unauthenticated are also allowed by an AuthorizationPolicy without a rule for the
+	// method.
+	AuthOptional
+
+	// AuthPublic allows the request through without authentication.
+	//
+	// Requests are also allowed by an AuthorizationPolicy without a rule for the method, methods with a rule must
+	// use a Public requirement.
+	AuthPublic
+
+	// AuthRequireOnline requires the request to be authenticated with online credentials.
+	AuthRequireOnline
+)
+
+const (
+	// HealthMethods matches the methods of the standard gRPC health service.
+	HealthMethods = "/grpc.health.v1.Health/*"
+
+	// ReflectionMethods matches the methods of the standard gRPC reflection service.
+	ReflectionMethods = "/grpc.reflection.v1.ServerReflection/*"
+
+	// ReflectionAlphaMethods matches the methods of the v1alpha gRPC reflection service.
+	ReflectionAlphaMethods = "/grpc.reflection.v1alpha.ServerReflection/*"
+)
+
+// String returns the name of the mode.
+func (m AuthMode) String() string {
+	switch m {
+	case AuthRequired:
+		return "required"
+	case AuthOptional:
+		return "optional"
+	case AuthPublic:
+		return "public"
+	case AuthRequireOnline:
+		return "require-online"
+	}
+
+	return fmt.Sprintf("AuthMode(%d)", int(m))
+}
+
+// MethodPolicy maps full gRPC method names (eg. "/grpcauth.test.Test/TestOnline") to the authentication mode
+// required to call them.
+//
+// Rules are matched against the full method name, exact matches take precedence, followed by patterns
+// (eg. "/grpcauth.test.Test/*" or "/grpc.reflection.*/*") in the order they were added. Methods not matching
+// any rule use the default mode.
+//
+// A MethodPolicy should be fully configured before it is used to serve requests.
+type MethodPolicy struct {
+	defaultMode AuthMode
+	rules       methodRules[AuthMode]
+}
+
+// NewMethodPolicy returns a new MethodPolicy using defaultMode for methods that do not match a rule.
+func NewMethodPolicy(defaultMode AuthMode) *MethodPolicy {
+	return &MethodPolicy{
+		defaultMode: defaultMode,
+		rules:       newMethodRules[AuthMode](),
+	}
+}
+
+// Set adds a rule for the full method name or pattern, patterns use the syntax of path.Match.
+func (p *MethodPolicy) Set(pattern string, mode AuthMode) error {
+	return p.rules.set(pattern, mode)
+}
+
+// Mode returns the authentication mode for the full method name.
+func (p *MethodPolicy) Mode(fullMethod string) AuthMode {
+	if mode, ok := p.rules.match(fullMethod); ok {
+		return mode
+	}
+
+	return p.defaultMode
+}
+
+// AuthFunc wraps the authentication function, applying the mode for the method being called.
+//
+// The method is determined with grpc.Method, when it is unavailable the default mode is used.
+func (p *MethodPolicy) AuthFunc(
+	authFunc func(ctx context.Context) (context.Context, error),
+) func(ctx context.Context) (context.Context, error) {
+	return func(ctx context.Context) (context.Context, error) {
+		mode := p.defaultMode
+		if method, ok := grpc.Method(ctx); ok {
+			mode = p.Mode(method)
+		}
+
+		return authenticateMode(ctx, mode, authFunc)
+	}
+}
+
+// authenticateMode calls the authentication function as required by the mode.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func authenticateMode(
+	ctx context.Context,
+	mode AuthMode,
+	authFunc func(ctx context.Context) (context.Context, error),
+) (context.Context, error) {
+	switch mode {
+	case AuthPublic:
+		return context.WithValue(ctx, unauthenticatedModeKey, mode), nil
+	case AuthOptional:
+		outCtx, err := authFunc(ctx)
+		if errors.Is(err, ErrCredentialsMissing) {
+			return context.WithValue(ctx, unauthenticatedModeKey, mode), nil
+		}
+
+		return outCtx, err
+	case AuthRequireOnline:
+		outCtx, err := authFunc(ctx)
+		if err != nil {
+			return outCtx, err
+		}
+
+		if pr, ok := PrincipalFromContext(outCtx); !ok || !pr.Online {
+			return ctx, status.Error(codes.Unauthenticated, "request requires an online token")
+		}
+
+		return outCtx, nil
+	case AuthRequired:
+	}
+
+	return authFunc(ctx)
+}
+
+// allowedUnauthenticated returns true when the authentication mode allowed the request through without a
+// principal.
+func allowedUnauthenticated(ctx context.Context) bool {
+	_, ok := ctx.Value(unauthenticatedModeKey).(AuthMode)
+
+	return ok
+}
+
+// methodRules maps full method names and patterns to values, exact matches take precedence, followed by patterns
+// in the order they were added.
+type methodRules[T any] struct {
+	exact    map[string]T
+	patterns []methodPattern[T]
+}
+
+type methodPattern[T any] struct {
+	pattern string
+	value   T
+}
+
+func newMethodRules[T any]() methodRules[T] {
+	return methodRules[T]{exact: map[string]T{}}
+}
+
+func (r *methodRules[T]) set(pattern string, value T) error {
+	if !isMethodPattern(pattern) {
+		r.exact[pattern] = value
+
+		return nil
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid method pattern '%s': %w", pattern, err)
+	}
+
+	r.patterns = append(r.patterns, methodPattern[T]{pattern: pattern, value: value})
+
+	return nil
+}
+
+func (r *methodRules[T]) match(fullMethod string) (T, bool) {
+	if value, ok := r.exact[fullMethod]; ok {
+		return value, true
+	}
+
+	for _, mp := range r.patterns {
+		if ok, _ := path.Match(mp.pattern, fullMethod); ok {
+			return mp.value, true
+		}
+	}
+
+	var zero T
+
+	return zero, false
+}
+
+func isMethodPattern(pattern string) bool {
+	for _, c := range pattern {
+		switch c {
+		case '*', '?', '[', '\\':
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,185 @@
+package grpcauth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"github.com/dosquad/go-grpcauth/test"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func testMethodPolicy(t *testing.T) *grpcauth.MethodPolicy {
+	t.Helper()
+
+	policy := grpcauth.NewMethodPolicy(grpcauth.AuthRequired)
+	for pattern, mode := range map[string]grpcauth.AuthMode{
+		grpcauth.HealthMethods:              grpcauth.AuthPublic,
+		"/grpc.reflection.*/*":              grpcauth.AuthPublic,
+		"/grpcauth.test.Test/*":             grpcauth.AuthOptional,
+		test.Test_TestOnline_FullMethodName: grpcauth.AuthRequireOnline,
+	} {
+		if err := policy.Set(pattern, mode); err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+	}
+
+	return policy
+}
+
+func TestPolicy_Mode(t *testing.T) {
+	tests := []struct {
+		method string
+		mode   grpcauth.AuthMode
+	}{
+		{"/grpc.health.v1.Health/Check", grpcauth.AuthPublic},
+		{"/grpc.health.v1.Health/Watch", grpcauth.AuthPublic},
+		{"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", grpcauth.AuthPublic},
+		{"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", grpcauth.AuthPublic},
+		{test.Test_TestOnline_FullMethodName, grpcauth.AuthRequireOnline},
+		{test.Test_TestOffline_FullMethodName, grpcauth.AuthOptional},
+		{"/other.Service/Method", grpcauth.AuthRequired},
+	}
+
+	policy := testMethodPolicy(t)
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if mode := policy.Mode(tt.method); mode != tt.mode {
+				t.Errorf("expected mode to be '%s', received '%s'", tt.mode, mode)
+			}
+		})
+	}
+}
+
+func TestPolicy_InvalidPattern(t *testing.T) {
+	policy := grpcauth.NewMethodPolicy(grpcauth.AuthRequired)
+	if err := policy.Set("/grpcauth.test.Test/[", grpcauth.AuthPublic); err == nil {
+		t.Error("expected error to be returned, but error returned nil")
+	}
+}
+
+func TestPolicy_AuthFunc(t *testing.T) {
+	tests := []struct {
+		name, method, header string
+		expectedCode         codes.Code
+		expectedUser         any
+	}{
+		{"public method without credentials", "/grpc.health.v1.Health/Check", "", codes.OK, nil},
+		{"public method ignores invalid credentials", "/grpc.health.v1.Health/Check", "Bearer invalid", codes.OK, nil},
+		{"optional method without credentials", test.Test_TestOffline_FullMethodName, "", codes.OK, nil},
+		{
+			"optional method with valid credentials",
+			test.Test_TestOffline_FullMethodName,
+			"Bearer valid-offline-token",
+			codes.OK,
+			"offline-user",
+		},
+		{
+			"optional method with invalid credentials",
+			test.Test_TestOffline_FullMethodName,
+			"Bearer invalid",
+			codes.Unauthenticated,
+			nil,
+		},
+		{
+			"online method with online token",
+			test.Test_TestOnline_FullMethodName,
+			"Bearer valid-online-token",
+			codes.OK,
+			"online-user",
+		},
+		{
+			"online method with offline token",
+			test.Test_TestOnline_FullMethodName,
+			"Bearer valid-offline-token",
+			codes.Unauthenticated,
+			nil,
+		},
+		{"required method without credentials", "/other.Service/Method", "", codes.Unauthenticated, nil},
+		{
+			"required method with offline token",
+			"/other.Service/Method",
+			"Bearer valid-offline-token",
+			codes.OK,
+			"offline-user",
+		},
+	}
+
+	authFunc := testMethodPolicy(t).AuthFunc(grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tt.header != "" {
+				md.Set("authorization", tt.header)
+			}
+
+			authCtx, err := authFunc(incomingContext(tt.method, md))
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Errorf("expected error code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+
+			if v := authCtx.Value(grpcauth.Username); v != tt.expectedUser {
+				t.Errorf("expected context value grpcauth.Username to be '%v', received '%v'", tt.expectedUser, v)
+			}
+		})
+	}
+}
+
+func TestPolicy_AuthFunc_NoMethodUsesDefault(t *testing.T) {
+	policy := grpcauth.NewMethodPolicy(grpcauth.AuthPublic)
+
+	_, err := policy.AuthFunc(grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc))(context.Background())
+	if err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+}
+
+func TestPolicy_AuthFunc_OptionalOtherCredentials(t *testing.T) {
+	header := grpcauth.NewHeaderAuthenticator(grpcauth.HeaderConfig{
+		Header: "x-api-key",
+		Verify: func(ctx context.Context, token string) (context.Context, string, bool, error) {
+			switch token {
+			case "valid-key":
+				return ctx, "key-user", true, nil
+			case "unavailable-key":
+				return ctx, "", false, grpcauth.ErrVerifierUnavailable
+			}
+
+			return ctx, "", false, grpcauth.ErrInvalidCredentials
+		},
+	})
+
+	authFunc := testMethodPolicy(t).AuthFunc(
+		header.Or(grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc)),
+	)
+
+	tests := []struct {
+		name         string
+		md           metadata.MD
+		expectedCode codes.Code
+		expectedUser any
+	}{
+		{"without credentials", metadata.MD{}, codes.OK, nil},
+		{"unknown authorization scheme", metadata.Pairs("authorization", "Unknown abc"), codes.OK, nil},
+		{"valid header", metadata.Pairs("x-api-key", "valid-key"), codes.OK, "key-user"},
+		{"invalid header", metadata.Pairs("x-api-key", "invalid-key"), codes.Unauthenticated, nil},
+		{"verifier unavailable", metadata.Pairs("x-api-key", "unavailable-key"), codes.Unavailable, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authCtx, err := authFunc(incomingContext(test.Test_TestOffline_FullMethodName, tt.md))
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Errorf("expected error code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+
+			if v := authCtx.Value(grpcauth.Username); v != tt.expectedUser {
+				t.Errorf("expected context value grpcauth.Username to be '%v', received '%v'", tt.expectedUser, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package grpcauth
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+const principalKey contextValue = "principal"
+
+// Principal describes the identity authenticated for a request.
+type Principal struct {
+	// Subject is the authenticated identity, for the built-in schemes it is the same value stored as Username.
+	Subject string
+
+	// Scheme is the authorization scheme the principal was authenticated with (eg. "Basic" or "Bearer").
+	Scheme string
+
+	// Online indicates the credentials were verified against the authority during this request.
+	Online bool
+
+	// AuthenticatedAt is the time the credentials were verified.
+	AuthenticatedAt time.Time
+
+	// ExpiresAt is the time the credentials expire, it is zero when unknown.
+	ExpiresAt time.Time
+
+	// Scopes granted to the principal.
+	Scopes []string
+
+	// Roles granted to the principal.
+	Roles []string
+
+	// Claims are arbitrary attributes supplied by the verifier.
+	Claims map[string]any
+}
+
+// HasScope returns true if the principal has been granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+
+	return slices.Contains(p.Scopes, scope)
+}
+
+// HasRole returns true if the principal has been granted the role.
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+
+	return slices.Contains(p.Roles, role)
+}
+
+// Claim returns the claim value and if it was present.
+func (p *Principal) Claim(name string) (any, bool) {
+	if p == nil {
+		return nil, false
+	}
+
+	v, ok := p.Claims[name]
+
+	return v, ok
+}
+
+// NewContextWithPrincipal returns a copy of the context carrying the principal.
+//
+// The legacy Username and Online context values are also populated from the principal.
+func NewContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalKey, p)
+	ctx = context.WithValue(ctx, Username, p.Subject)
+	ctx = context.WithValue(ctx, Online, p.Online)
+
+	return ctx
+}
+
+// PrincipalFromContext returns the principal stored in the context and if it was present.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+
+	return p, ok && p != nil
+}
+
+// newContextWithAuthenticated stores the principal for a successful verification, the expiry, scopes, roles and
+// claims from a principal attached by the verifier are retained.
+func newContextWithAuthenticated(
+	inCtx, outCtx context.Context,
+	scheme, subject string,
+	online bool,
+) context.Context {
+	p := &Principal{
+		Subject:         subject,
+		Scheme:          scheme,
+		Online:          online,
+		AuthenticatedAt: time.Now(),
+	}
+
+	if vp, ok := PrincipalFromContext(outCtx); ok {
+		if ip, inOk := PrincipalFromContext(inCtx); !inOk || ip != vp {
+			p.ExpiresAt = vp.ExpiresAt
+			p.Scopes = vp.Scopes
+			p.Roles = vp.Roles
+			p.Claims = vp.Claims
+		}
+	}
+
+	return NewContextWithPrincipal(outCtx, p)
+}
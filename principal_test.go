@@ -0,0 +1,120 @@
+package grpcauth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPrincipal_FromVerify(t *testing.T) {
+	tests := []struct {
+		name, header, user, scheme string
+		online                     bool
+	}{
+		{
+			"basic",
+			"Basic " + base64.StdEncoding.EncodeToString([]byte("valid-user:valid-pass")),
+			"valid-user",
+			"Basic",
+			true,
+		},
+		{"bearer online", "Bearer valid-online-token", "online-user", "Bearer", true},
+		{"bearer offline", "Bearer valid-offline-token", "offline-user", "Bearer", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+				"authorization": []string{tt.header},
+			})
+
+			authCtx, err := grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc)(ctx)
+			if err != nil {
+				t.Errorf("expected error to be nil, returned '%v'", err)
+			}
+
+			p, ok := grpcauth.PrincipalFromContext(authCtx)
+			if !ok {
+				t.Fatal("expected principal to be present in context")
+			}
+
+			if p.Subject != tt.user {
+				t.Errorf("expected principal.Subject to be '%s', received '%s'", tt.user, p.Subject)
+			}
+
+			if p.Scheme != tt.scheme {
+				t.Errorf("expected principal.Scheme to be '%s', received '%s'", tt.scheme, p.Scheme)
+			}
+
+			if p.Online != tt.online {
+				t.Errorf("expected principal.Online to be '%t', received '%t'", tt.online, p.Online)
+			}
+
+			if p.AuthenticatedAt.IsZero() {
+				t.Error("expected principal.AuthenticatedAt to be set")
+			}
+
+			if v := authCtx.Value(grpcauth.Username); v != tt.user {
+				t.Errorf("expected context value grpcauth.Username to be '%s', received '%s'", tt.user, v)
+			}
+
+			if v := authCtx.Value(grpcauth.Online); v != tt.online {
+				t.Errorf("expected context value grpcauth.Online to be '%t', received '%t'", tt.online, v)
+			}
+		})
+	}
+}
+
+func TestPrincipal_RetainsVerifierAttributes(t *testing.T) {
+	bearerFunc := func(ctx context.Context, _ string) (context.Context, string, bool, bool) {
+		return grpcauth.NewContextWithPrincipal(ctx, &grpcauth.Principal{
+			Scopes: []string{"read"},
+			Roles:  []string{"admin"},
+			Claims: map[string]any{"tenant": "acme"},
+		}), "scoped-user", false, true
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"Bearer token"},
+	})
+
+	authCtx, err := grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerFunc)(ctx)
+	if err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	p, ok := grpcauth.PrincipalFromContext(authCtx)
+	if !ok {
+		t.Fatal("expected principal to be present in context")
+	}
+
+	if p.Subject != "scoped-user" {
+		t.Errorf("expected principal.Subject to be '%s', received '%s'", "scoped-user", p.Subject)
+	}
+
+	if !p.HasScope("read") || p.HasScope("write") {
+		t.Errorf("expected principal.Scopes to contain only 'read', received '%v'", p.Scopes)
+	}
+
+	if !p.HasRole("admin") {
+		t.Errorf("expected principal.Roles to contain 'admin', received '%v'", p.Roles)
+	}
+
+	if v, ok := p.Claim("tenant"); !ok || v != "acme" {
+		t.Errorf("expected principal claim 'tenant' to be '%s', received '%v'", "acme", v)
+	}
+}
+
+func TestPrincipal_MissingFromContext(t *testing.T) {
+	if _, ok := grpcauth.PrincipalFromContext(context.Background()); ok {
+		t.Error("expected principal not to be present in context")
+	}
+
+	var p *grpcauth.Principal
+	if p.HasScope("read") || p.HasRole("admin") {
+		t.Error("expected nil principal to have no scopes or roles")
+	}
+}
@@ -0,0 +1,180 @@
+package grpcauth
+
+import (
+	"context"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// RedactionPolicy clears response fields annotated with the (grpcauth.sensitive) option unless the authenticated
+// principal has any of the scopes, it is evaluated after the handler returns.
+//
+//	message Profile {
+//	    string email = 1 [(grpcauth.sensitive) = { scopes: ["profile.email"], mask: "***" }];
+//	}
+//
+// Fields with a mask have string and bytes values (including repeated values and map values) replaced with the
+// mask, other fields are cleared. Annotated fields of nested messages, repeated messages and map values are also
+// redacted. Responses are copied before they are redacted so messages shared by the handler are not modified.
+type RedactionPolicy struct {
+	sensitive *annotatedMessages
+}
+
+// NewRedactionPolicy returns a new RedactionPolicy.
+func NewRedactionPolicy() *RedactionPolicy {
+	return &RedactionPolicy{
+		sensitive: &annotatedMessages{annotated: func(fd protoreflect.FieldDescriptor) bool {
+			_, ok := fieldSensitive(fd)
+
+			return ok
+		}},
+	}
+}
+
+// Redact returns the response with the annotated fields the principal from the context can not read redacted,
+// responses that are not protobuf messages or without annotated fields are returned unchanged.
+func (p *RedactionPolicy) Redact(ctx context.Context, resp any) any {
+	msg, ok := resp.(proto.Message)
+	if !ok || !p.sensitive.has(msg.ProtoReflect().Descriptor()) {
+		return resp
+	}
+
+	pr, _ := PrincipalFromContext(ctx)
+
+	msg = proto.Clone(msg)
+	redactMessage(pr, msg.ProtoReflect())
+
+	return msg
+}
+
+// UnaryServerInterceptor returns an interceptor that redacts unary responses, it must be installed after the
+// authentication interceptor.
+func (p *RedactionPolicy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		return p.Redact(ctx, resp), nil
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that redacts each message sent on a stream, it must be installed
+// after the authentication interceptor.
+func (p *RedactionPolicy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &redactionServerStream{ServerStream: ss, policy: p})
+	}
+}
+
+// redactMessage redacts the annotated fields of the message the principal can not read.
+func redactMessage(pr *Principal, m protoreflect.Message) {
+	fields := m.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if !m.Has(fd) {
+			continue
+		}
+
+		if sensitive, ok := fieldSensitive(fd); ok {
+			if !slices.ContainsFunc(sensitive.GetScopes(), pr.HasScope) {
+				redactField(m, fd, sensitive.GetMask())
+			}
+
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				continue
+			}
+
+			m.Mutable(fd).Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				redactMessage(pr, v.Message())
+
+				return true
+			})
+		case fd.IsList() && fd.Message() != nil:
+			list := m.Mutable(fd).List()
+			for j := range list.Len() {
+				redactMessage(pr, list.Get(j).Message())
+			}
+		case fd.Message() != nil:
+			redactMessage(pr, m.Mutable(fd).Message())
+		}
+	}
+}
+
+// redactField replaces the string and bytes values of the field with the mask, other fields or fields without a
+// mask are cleared.
+func redactField(m protoreflect.Message, fd protoreflect.FieldDescriptor, mask string) {
+	kind := fd.Kind()
+	if fd.IsMap() {
+		kind = fd.MapValue().Kind()
+	}
+
+	var masked protoreflect.Value
+
+	switch kind { //nolint:exhaustive // other kinds are cleared.
+	case protoreflect.StringKind:
+		masked = protoreflect.ValueOfString(mask)
+	case protoreflect.BytesKind:
+		masked = protoreflect.ValueOfBytes([]byte(mask))
+	}
+
+	switch {
+	case mask == "" || !masked.IsValid():
+		m.Clear(fd)
+	case fd.IsMap():
+		values := m.Mutable(fd).Map()
+
+		var keys []protoreflect.MapKey
+
+		values.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, k)
+
+			return true
+		})
+
+		for _, k := range keys {
+			values.Set(k, masked)
+		}
+	case fd.IsList():
+		list := m.Mutable(fd).List()
+		for i := range list.Len() {
+			list.Set(i, masked)
+		}
+	default:
+		m.Set(fd, masked)
+	}
+}
+
+// fieldSensitive returns the (grpcauth.sensitive) option of the field and if it was present.
+func fieldSensitive(fd protoreflect.FieldDescriptor) (*Sensitive, bool) {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || !proto.HasExtension(opts, E_Sensitive) {
+		return nil, false
+	}
+
+	sensitive, ok := proto.GetExtension(opts, E_Sensitive).(*Sensitive)
+
+	return sensitive, ok
+}
+
+// redactionServerStream redacts each message sent on the stream.
+type redactionServerStream struct {
+	grpc.ServerStream
+
+	policy *RedactionPolicy
+}
+
+func (s *redactionServerStream) SendMsg(m any) error {
+	//nolint:wrapcheck // stream errors are returned unchanged.
+	return s.ServerStream.SendMsg(s.policy.Redact(s.Context(), m))
+}
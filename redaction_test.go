@@ -0,0 +1,136 @@
+package grpcauth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"github.com/dosquad/go-grpcauth/test"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func testProfile() *test.Profile {
+	return &test.Profile{
+		Name:   "alice",
+		Email:  "alice@example.com",
+		Salary: 100,
+		Phones: []string{"555-0100", "555-0101"},
+		Notes:  map[string]string{"review": "good"},
+	}
+}
+
+func TestRedactionPolicy_Redact(t *testing.T) {
+	redacted := &test.Profile{
+		Name:   "alice",
+		Email:  "***",
+		Phones: []string{"***", "***"},
+		Notes:  map[string]string{"review": "***"},
+	}
+
+	emailOnly := proto.Clone(redacted).(*test.Profile) //nolint:forcetypeassert // test code
+	emailOnly.Email = "alice@example.com"
+
+	nested := func(p *test.Profile) *test.Profile {
+		return &test.Profile{
+			Name:    "bob",
+			Manager: p,
+			Reports: []*test.Profile{p, p},
+			Peers:   map[string]*test.Profile{"alice": p},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		principal *grpcauth.Principal
+		resp      any
+		expected  any
+	}{
+		{"admin", &grpcauth.Principal{Scopes: []string{"admin"}}, testProfile(), testProfile()},
+		{"any scope", &grpcauth.Principal{Scopes: []string{"profile.email"}}, testProfile(), emailOnly},
+		{"without scopes", &grpcauth.Principal{}, testProfile(), redacted},
+		{"unauthenticated", nil, testProfile(), redacted},
+		{"nested", &grpcauth.Principal{}, nested(testProfile()), nested(redacted)},
+		{"unset fields", nil, &test.Profile{Name: "alice"}, &test.Profile{Name: "alice"}},
+		{"without annotations", nil, &test.Response{User: "alice"}, &test.Response{User: "alice"}},
+	}
+
+	p := grpcauth.NewRedactionPolicy()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = grpcauth.NewContextWithPrincipal(ctx, tt.principal)
+			}
+
+			original := proto.Clone(tt.resp.(proto.Message)) //nolint:forcetypeassert // test code
+
+			resp := p.Redact(ctx, tt.resp)
+			if !proto.Equal(resp.(proto.Message), tt.expected.(proto.Message)) { //nolint:forcetypeassert // test code
+				t.Errorf("expected response to be '%v', received '%v'", tt.expected, resp)
+			}
+
+			if !proto.Equal(tt.resp.(proto.Message), original) { //nolint:forcetypeassert // test code
+				t.Errorf("expected original response to be unchanged, received '%v'", tt.resp)
+			}
+		})
+	}
+}
+
+func TestRedactionPolicy_Redact_NotMessage(t *testing.T) {
+	resp := struct{ Email string }{"alice@example.com"}
+
+	if v := grpcauth.NewRedactionPolicy().Redact(context.Background(), resp); v != resp {
+		t.Errorf("expected response to be unchanged, received '%v'", v)
+	}
+}
+
+type testRedactionServerStream struct {
+	grpc.ServerStream
+
+	sent []any
+}
+
+func (s *testRedactionServerStream) Context() context.Context { return context.Background() }
+
+func (s *testRedactionServerStream) SendMsg(m any) error {
+	s.sent = append(s.sent, m)
+
+	return nil
+}
+
+func TestRedactionPolicy_Interceptors(t *testing.T) {
+	p := grpcauth.NewRedactionPolicy()
+	expected := &test.Profile{
+		Name:   "alice",
+		Email:  "***",
+		Phones: []string{"***", "***"},
+		Notes:  map[string]string{"review": "***"},
+	}
+
+	resp, err := p.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{},
+		func(context.Context, any) (any, error) {
+			return testProfile(), nil
+		})
+	if err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	if !proto.Equal(resp.(proto.Message), expected) { //nolint:forcetypeassert // test code
+		t.Errorf("expected unary response to be '%v', received '%v'", expected, resp)
+	}
+
+	ss := &testRedactionServerStream{}
+
+	err = p.StreamServerInterceptor()(nil, ss, &grpc.StreamServerInfo{}, func(_ any, ss grpc.ServerStream) error {
+		return ss.SendMsg(testProfile())
+	})
+	if err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	if len(ss.sent) != 1 || !proto.Equal(ss.sent[0].(proto.Message), expected) { //nolint:forcetypeassert // test code
+		t.Errorf("expected stream message to be '%v', received '%v'", expected, ss.sent)
+	}
+}
@@ -0,0 +1,53 @@
+package grpcauth
+
+import (
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+)
+
+// replaySweepThreshold is the number of remembered values before expired values are swept.
+const replaySweepThreshold = 1024
+
+// replayCache remembers single-use values (eg. nonces) until they expire, so replays can be rejected.
+type replayCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	seen      map[[sha256.Size]byte]time.Time
+	nextSweep time.Time
+}
+
+func newReplayCache(ttl time.Duration) *replayCache {
+	return &replayCache{
+		ttl:  ttl,
+		seen: map[[sha256.Size]byte]time.Time{},
+	}
+}
+
+// remember records the value made up of parts, returning false if it has been seen and not expired.
+func (c *replayCache) remember(now time.Time, parts ...string) bool {
+	key := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if expires, ok := c.seen[key]; ok && now.Before(expires) {
+		return false
+	}
+
+	if len(c.seen) >= replaySweepThreshold && now.After(c.nextSweep) {
+		for k, expires := range c.seen {
+			if !now.Before(expires) {
+				delete(c.seen, k)
+			}
+		}
+
+		c.nextSweep = now.Add(c.ttl)
+	}
+
+	c.seen[key] = now.Add(c.ttl)
+
+	return true
+}
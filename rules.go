@@ -0,0 +1,181 @@
+package grpcauth
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// RulePolicyConfig is the configuration for NewRulePolicy.
+type RulePolicyConfig struct {
+	// Default is the rule for methods without a (grpcauth.rule) option, when nil they require authentication.
+	Default *Rule
+
+	// Files are the descriptors the method options are read from, defaults to protoregistry.GlobalFiles.
+	Files *protoregistry.Files
+}
+
+// RulePolicy authenticates and authorizes requests using the (grpcauth.rule) option declared on the method in the
+// .proto file, so the requirements live next to the API definition.
+//
+//	import "github.com/dosquad/go-grpcauth/grpcauth.proto";
+//
+//	rpc TestOnline(EmptyRequest) returns (Response) {
+//	    option (grpcauth.rule) = { scopes: ["admin"], require_online: true };
+//	}
+//
+// Public methods are allowed without authentication, require_online methods must be authenticated with online
+// credentials, and the principal must have any of the roles and all of the scopes. Requests that fail
+// authentication (including offline credentials for require_online methods) are rejected with
+// codes.Unauthenticated, principals without the roles or scopes are rejected with codes.PermissionDenied.
+type RulePolicy struct {
+	cfg   RulePolicyConfig
+	rules sync.Map // full method name of methods found in the descriptors -> *Rule
+}
+
+// NewRulePolicy returns a new RulePolicy.
+func NewRulePolicy(cfg RulePolicyConfig) *RulePolicy {
+	if cfg.Files == nil {
+		cfg.Files = protoregistry.GlobalFiles
+	}
+
+	return &RulePolicy{cfg: cfg}
+}
+
+// Rule returns the rule for the full method name, the default rule is returned for methods without a
+// (grpcauth.rule) option or that are not registered.
+func (p *RulePolicy) Rule(fullMethod string) *Rule {
+	if v, ok := p.rules.Load(fullMethod); ok {
+		rule, _ := v.(*Rule)
+
+		return rule
+	}
+
+	rule, found := methodRule(p.cfg.Files, fullMethod)
+	if rule == nil {
+		rule = p.cfg.Default
+	}
+
+	// only methods in the descriptors are cached, so unknown methods (eg. from grpc.UnknownServiceHandler) do not
+	// grow the cache.
+	if found {
+		p.rules.Store(fullMethod, rule)
+	}
+
+	return rule
+}
+
+// Authorize authenticates the request with authFunc as required by the rule for the full method name, and checks
+// the principal has the roles and scopes of the rule.
+func (p *RulePolicy) Authorize(
+	ctx context.Context,
+	fullMethod string,
+	authFunc func(ctx context.Context) (context.Context, error),
+) (context.Context, error) {
+	rule := p.Rule(fullMethod)
+
+	mode := AuthRequired
+
+	switch {
+	case rule.GetPublic():
+		mode = AuthPublic
+	case rule.GetRequireOnline():
+		mode = AuthRequireOnline
+	}
+
+	outCtx, err := authenticateMode(ctx, mode, authFunc)
+	if err != nil {
+		return outCtx, err
+	}
+
+	if err := authorizeRequirement(outCtx, Requirement{
+		Roles:  rule.GetRoles(),
+		Scopes: rule.GetScopes(),
+		Public: rule.GetPublic(),
+	}); err != nil {
+		return ctx, err
+	}
+
+	return outCtx, nil
+}
+
+// UnaryServerInterceptor returns an interceptor that authenticates and authorizes unary requests, it is used in
+// place of grpcauth.UnaryServerInterceptor.
+func (p *RulePolicy) UnaryServerInterceptor(
+	authFunc func(ctx context.Context) (context.Context, error),
+) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		outCtx, err := p.Authorize(ctx, info.FullMethod, authFunc)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(outCtx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that authenticates and authorizes streaming requests, it is used
+// in place of grpcauth.StreamServerInterceptor.
+func (p *RulePolicy) StreamServerInterceptor(
+	authFunc func(ctx context.Context) (context.Context, error),
+) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		outCtx, err := p.Authorize(ss.Context(), info.FullMethod, authFunc)
+		if err != nil {
+			return err
+		}
+
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = outCtx
+
+		return handler(srv, wrapped)
+	}
+}
+
+// MethodRule returns the (grpcauth.rule) option of the full method name (eg. "/grpcauth.test.Test/TestOnline")
+// from the descriptors and if it was present.
+func MethodRule(files *protoregistry.Files, fullMethod string) (*Rule, bool) {
+	rule, _ := methodRule(files, fullMethod)
+
+	return rule, rule != nil
+}
+
+// methodRule returns the (grpcauth.rule) option of the full method name, or nil when it is not present, and if
+// the method was found in the descriptors.
+func methodRule(files *protoregistry.Files, fullMethod string) (*Rule, bool) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return nil, false
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, false
+	}
+
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, false
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, false
+	}
+
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	if !ok || !proto.HasExtension(opts, E_Rule) {
+		return nil, true
+	}
+
+	rule, _ := proto.GetExtension(opts, E_Rule).(*Rule)
+
+	return rule, true
+}
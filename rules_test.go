@@ -0,0 +1,198 @@
+package grpcauth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"github.com/dosquad/go-grpcauth/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// testRuleFiles returns descriptors for the service "grpcauth.rules.Rules" with a method for each rule.
+func testRuleFiles(t *testing.T, rules map[string]*grpcauth.Rule) *protoregistry.Files {
+	t.Helper()
+
+	sd := &descriptorpb.ServiceDescriptorProto{Name: proto.String("Rules")}
+
+	for name, rule := range rules {
+		opts := &descriptorpb.MethodOptions{}
+		if rule != nil {
+			proto.SetExtension(opts, grpcauth.E_Rule, rule)
+		}
+
+		sd.Method = append(sd.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".grpcauth.rules.Empty"),
+			OutputType: proto.String(".grpcauth.rules.Empty"),
+			Options:    opts,
+		})
+	}
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("rules_test.proto"),
+		Package:     proto.String("grpcauth.rules"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Empty")}},
+		Service:     []*descriptorpb.ServiceDescriptorProto{sd},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return files
+}
+
+func TestMethodRule(t *testing.T) {
+	rule, ok := grpcauth.MethodRule(protoregistry.GlobalFiles, test.Test_TestOnline_FullMethodName)
+	if !ok {
+		t.Fatal("expected rule to be present, returned false")
+	}
+
+	if !rule.GetRequireOnline() || rule.GetPublic() {
+		t.Errorf("expected rule to require an online token, received '%v'", rule)
+	}
+
+	for _, method := range []string{
+		test.Test_TestOffline_FullMethodName,
+		"/grpcauth.test.Test/Unknown",
+		"/grpcauth.test.Unknown/TestOnline",
+		"/grpcauth.test.EmptyRequest/TestOnline",
+		"invalid",
+	} {
+		if _, ok := grpcauth.MethodRule(protoregistry.GlobalFiles, method); ok {
+			t.Errorf("expected rule for '%s' to not be present, returned true", method)
+		}
+	}
+}
+
+func TestRulePolicy_Rule_Default(t *testing.T) {
+	def := &grpcauth.Rule{Public: true}
+	p := grpcauth.NewRulePolicy(grpcauth.RulePolicyConfig{Default: def})
+
+	if rule := p.Rule(test.Test_TestOffline_FullMethodName); rule != def {
+		t.Errorf("expected default rule, received '%v'", rule)
+	}
+
+	if rule := p.Rule(test.Test_TestOnline_FullMethodName); !rule.GetRequireOnline() {
+		t.Errorf("expected rule to require an online token, received '%v'", rule)
+	}
+}
+
+func TestRulePolicy_Rule_UnknownNotCached(t *testing.T) {
+	def := &grpcauth.Rule{Public: true}
+	files := &protoregistry.Files{}
+	p := grpcauth.NewRulePolicy(grpcauth.RulePolicyConfig{Default: def, Files: files})
+
+	if rule := p.Rule("/grpcauth.rules.Rules/Admin"); rule != def {
+		t.Errorf("expected default rule, received '%v'", rule)
+	}
+
+	// methods that were not found are looked up again.
+	fd, err := testRuleFiles(t, map[string]*grpcauth.Rule{"Admin": {Roles: []string{"admin"}}}).FindFileByPath(
+		"rules_test.proto",
+	)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if rule := p.Rule("/grpcauth.rules.Rules/Admin"); len(rule.GetRoles()) != 1 || rule.GetRoles()[0] != "admin" {
+		t.Errorf("expected rule to require the 'admin' role, received '%v'", rule)
+	}
+}
+
+func TestRulePolicy_Authorize(t *testing.T) {
+	p := grpcauth.NewRulePolicy(grpcauth.RulePolicyConfig{
+		Files: testRuleFiles(t, map[string]*grpcauth.Rule{
+			"Public":  {Public: true},
+			"Online":  {RequireOnline: true},
+			"Admin":   {Roles: []string{"admin", "operator"}, Scopes: []string{"write"}},
+			"Default": nil,
+		}),
+	})
+
+	principals := map[string]*grpcauth.Principal{
+		"admin":    {Subject: "admin", Online: true, Roles: []string{"admin"}, Scopes: []string{"read", "write"}},
+		"operator": {Subject: "operator", Roles: []string{"operator"}, Scopes: []string{"read"}},
+	}
+
+	authFunc := func(ctx context.Context) (context.Context, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, subject := range md.Get("subject") {
+			if pr, ok := principals[subject]; ok {
+				return grpcauth.NewContextWithPrincipal(ctx, pr), nil
+			}
+		}
+
+		return ctx, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	tests := []struct {
+		name, method, subject string
+		expectedCode          codes.Code
+	}{
+		{"public without credentials", "/grpcauth.rules.Rules/Public", "", codes.OK},
+		{"online with online principal", "/grpcauth.rules.Rules/Online", "admin", codes.OK},
+		{"online with offline principal", "/grpcauth.rules.Rules/Online", "operator", codes.Unauthenticated},
+		{"roles and scopes", "/grpcauth.rules.Rules/Admin", "admin", codes.OK},
+		{"missing scope", "/grpcauth.rules.Rules/Admin", "operator", codes.PermissionDenied},
+		{"without credentials", "/grpcauth.rules.Rules/Admin", "", codes.Unauthenticated},
+		{"no rule option", "/grpcauth.rules.Rules/Default", "operator", codes.OK},
+		{"no rule option without credentials", "/grpcauth.rules.Rules/Default", "", codes.Unauthenticated},
+		{"unknown method", "/grpcauth.rules.Rules/Unknown", "operator", codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("subject", tt.subject))
+
+			called := false
+
+			_, err := p.UnaryServerInterceptor(authFunc)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(ctx context.Context, _ any) (any, error) {
+					called = true
+
+					if pr, ok := grpcauth.PrincipalFromContext(ctx); tt.subject != "" && (!ok || pr.Subject != tt.subject) {
+						t.Errorf("expected principal subject to be '%s', received '%v'", tt.subject, pr)
+					}
+
+					return struct{}{}, nil
+				})
+			if status.Code(err) != tt.expectedCode || called != (tt.expectedCode == codes.OK) {
+				t.Errorf("expected unary status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+
+			called = false
+
+			err = p.StreamServerInterceptor(authFunc)(nil, &testAuthzServerStream{ctx: ctx},
+				&grpc.StreamServerInfo{FullMethod: tt.method}, func(_ any, ss grpc.ServerStream) error {
+					called = true
+
+					if pr, ok := grpcauth.PrincipalFromContext(ss.Context()); tt.subject != "" && (!ok || pr.Subject != tt.subject) {
+						t.Errorf("expected principal subject to be '%s', received '%v'", tt.subject, pr)
+					}
+
+					return nil
+				})
+			if status.Code(err) != tt.expectedCode || called != (tt.expectedCode == codes.OK) {
+				t.Errorf("expected stream status code to be '%s', received '%s'", tt.expectedCode, status.Code(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// SignatureScheme is the authorization scheme for HMAC-SHA256 request signatures.
+const SignatureScheme = "GRPC-HMAC-SHA256"
+
+const (
+	signatureDateHeader      = "x-signature-date"
+	signatureNonceHeader     = "x-signature-nonce"
+	signatureContentHeader   = "x-content-sha256"
+	signatureDateFormat      = "20060102T150405Z"
+	signatureNonceLength     = 16
+	signatureContentClaim    = "content_sha256"
+	defaultSignatureWindow   = 5 * time.Minute
+	unsignedSignaturePayload = "UNSIGNED-PAYLOAD"
+)
+
+// ErrSigningKeyNotFound is returned by a SigningKeyring when the key ID is unknown.
+var ErrSigningKeyNotFound = errors.New("signing key not found")
+
+// SigningKey is a shared secret used to sign requests.
+type SigningKey struct {
+	// ID identifies the key, it is sent with every signed request.
+	ID string
+
+	// Secret is the HMAC-SHA256 key.
+	Secret []byte
+
+	// Subject is the principal subject for requests signed with the key, defaults to the ID.
+	Subject string
+
+	// NotAfter is when the key stops being accepted, the key does not expire when zero.
+	NotAfter time.Time
+}
+
+// SigningKeyring returns signing keys by ID.
+type SigningKeyring interface {
+	// SigningKey returns the key with the ID, or ErrSigningKeyNotFound when the key does not exist.
+	SigningKey(ctx context.Context, id string) (SigningKey, error)
+}
+
+// SigningKeyringFunc is a function that implements SigningKeyring.
+type SigningKeyringFunc func(ctx context.Context, id string) (SigningKey, error)
+
+// SigningKey calls the function.
+func (f SigningKeyringFunc) SigningKey(ctx context.Context, id string) (SigningKey, error) {
+	return f(ctx, id)
+}
+
+// SigningKeySource returns the current signing key for a RequestSigner.
+type SigningKeySource interface {
+	// Current returns the key requests are signed with, or ErrSigningKeyNotFound when there is no current key.
+	Current(ctx context.Context) (SigningKey, error)
+}
+
+// SigningKeys is an in-memory SigningKeyring and SigningKeySource that supports key rotation, it is safe for concurrent use.
+//
+// The most recently added key is the current key used by a RequestSigner, previous keys are still accepted until
+// they are removed or expire so clients can be rotated without failing requests.
+type SigningKeys struct {
+	mu      sync.RWMutex
+	keys    map[string]SigningKey
+	current string
+}
+
+// NewSigningKeys returns a new SigningKeys containing the keys, the last key is the current key.
+func NewSigningKeys(keys ...SigningKey) *SigningKeys {
+	k := &SigningKeys{keys: map[string]SigningKey{}}
+	for _, key := range keys {
+		k.Rotate(key)
+	}
+
+	return k
+}
+
+// Rotate adds the key and makes it the current key.
+func (k *SigningKeys) Rotate(key SigningKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[key.ID] = key
+	k.current = key.ID
+}
+
+// Remove removes the key, requests signed with it are no longer accepted.
+func (k *SigningKeys) Remove(id string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.keys, id)
+
+	if k.current == id {
+		k.current = ""
+	}
+}
+
+// Current returns the current key, or ErrSigningKeyNotFound when there is no current key.
+func (k *SigningKeys) Current(_ context.Context) (SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[k.current]
+	if !ok {
+		return SigningKey{}, ErrSigningKeyNotFound
+	}
+
+	return key, nil
+}
+
+// SigningKey returns the key with the ID.
+func (k *SigningKeys) SigningKey(_ context.Context, id string) (SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[id]
+	if !ok {
+		return SigningKey{}, ErrSigningKeyNotFound
+	}
+
+	return key, nil
+}
+
+// RequestSignerConfig is the configuration for NewRequestSigner.
+type RequestSignerConfig struct {
+	// Keys provides the current signing key, eg. SigningKeys.
+	Keys SigningKeySource
+
+	// Metadata are the outgoing metadata keys included in the signature when present.
+	Metadata []string
+
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewRequestSigner returns a new RequestSigner.
+func NewRequestSigner(cfg RequestSignerConfig) *RequestSigner {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	headers := make([]string, 0, len(cfg.Metadata))
+	for _, h := range cfg.Metadata {
+		headers = append(headers, strings.ToLower(h))
+	}
+
+	slices.Sort(headers)
+	cfg.Metadata = slices.Compact(headers)
+
+	return &RequestSigner{cfg: cfg}
+}
+
+// RequestSigner signs requests with the current signing key, it is used as both PerRPCCredentials and a client
+// interceptor.
+//
+// The signature covers the method, the time, a random nonce, the configured metadata and the SHA-256 of the
+// deterministic protobuf serialization of the request message. The message hash is added by
+// UnaryClientInterceptor, streaming requests and requests without the interceptor are signed with
+// "UNSIGNED-PAYLOAD".
+type RequestSigner struct {
+	cfg RequestSignerConfig
+}
+
+type signatureContentKey struct{}
+
+// UnaryClientInterceptor returns an interceptor that hashes the request message for the signature.
+func (s *RequestSigner) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		sum, err := signatureContentHash(req)
+		if err != nil {
+			return err
+		}
+
+		return invoker(context.WithValue(ctx, signatureContentKey{}, sum), method, req, reply, cc, opts...)
+	}
+}
+
+// GetRequestMetadata signs the request and adds the signature headers.
+func (s *RequestSigner) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	ri, _ := credentials.RequestInfoFromContext(ctx)
+	if err := credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity); err != nil {
+		return nil, fmt.Errorf("unable to transfer Signature PerRPCCredentials: %w", err)
+	}
+
+	key, err := s.cfg.Keys.Current(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign request: %w", err)
+	}
+
+	nonce := make([]byte, signatureNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("unable to sign request: %w", err)
+	}
+
+	content, ok := ctx.Value(signatureContentKey{}).(string)
+	if !ok {
+		content = unsignedSignaturePayload
+	}
+
+	out, _ := metadata.FromOutgoingContext(ctx)
+
+	headers := make([]string, 0, len(s.cfg.Metadata))
+	for _, h := range s.cfg.Metadata {
+		if len(out.Get(h)) > 0 {
+			headers = append(headers, h)
+		}
+	}
+
+	req := signedRequest{
+		method:  ri.Method,
+		date:    s.cfg.Now().UTC().Format(signatureDateFormat),
+		nonce:   base64.RawURLEncoding.EncodeToString(nonce),
+		content: content,
+		headers: headers,
+		md:      out,
+	}
+
+	return map[string]string{
+		"Authorization": fmt.Sprintf("%s Credential=%s, SignedHeaders=%s, Signature=%s",
+			SignatureScheme, key.ID, strings.Join(headers, ";"), hex.EncodeToString(req.sign(key.Secret))),
+		signatureDateHeader:    req.date,
+		signatureNonceHeader:   req.nonce,
+		signatureContentHeader: req.content,
+	}, nil
+}
+
+// RequireTransportSecurity indicates whether the credentials requires
+// transport security.
+func (s *RequestSigner) RequireTransportSecurity() bool {
+	return true
+}
+
+// SignatureConfig is the configuration for NewSignatureAuthenticator.
+type SignatureConfig struct {
+	// Keyring returns the key for the key ID of the request.
+	Keyring SigningKeyring
+
+	// Window is how far the request time may be from the current time, nonces are remembered for twice the window
+	// to reject replays, defaults to 5 minutes.
+	Window time.Duration
+
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewSignatureAuthenticator returns an Authenticator for the GRPC-HMAC-SHA256 authorization scheme.
+func NewSignatureAuthenticator(cfg SignatureConfig) *SignatureAuthenticator {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultSignatureWindow
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &SignatureAuthenticator{
+		cfg:    cfg,
+		replay: newReplayCache(2 * cfg.Window), //nolint:mnd // the date may be up to a window either side of now.
+	}
+}
+
+// SignatureAuthenticator verifies requests signed by a RequestSigner.
+//
+// The request message is not available to authenticators, SignedContentUnaryServerInterceptor must be installed
+// after the authentication interceptor to check the message against the signed hash. Streaming requests are signed
+// with "UNSIGNED-PAYLOAD", so their messages are not covered by the signature and only the method, time, nonce and
+// metadata are verified. Replays are only detected by the same SignatureAuthenticator.
+type SignatureAuthenticator struct {
+	cfg    SignatureConfig
+	replay *replayCache
+}
+
+// Scheme returns "GRPC-HMAC-SHA256".
+func (a *SignatureAuthenticator) Scheme() string {
+	return SignatureScheme
+}
+
+// Authenticate verifies the request signature.
+func (a *SignatureAuthenticator) Authenticate(ctx context.Context, credentials string) (context.Context, error) {
+	key, req, err := a.verify(ctx, credentials)
+	if err != nil {
+		return ctx, verifierError(SignatureScheme, err)
+	}
+
+	subject := key.Subject
+	if subject == "" {
+		subject = key.ID
+	}
+
+	outCtx := NewContextWithPrincipal(ctx, &Principal{
+		Claims: map[string]any{"key_id": key.ID, signatureContentClaim: req.content},
+	})
+
+	return newContextWithAuthenticated(ctx, outCtx, SignatureScheme, subject, true), nil
+}
+
+//nolint:cyclop // each signature requirement is checked in turn.
+func (a *SignatureAuthenticator) verify(ctx context.Context, credentials string) (SigningKey, signedRequest, error) {
+	id, headers, sig, err := parseSignatureCredentials(credentials)
+	if err != nil {
+		return SigningKey{}, signedRequest{}, err
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	method, _ := grpc.Method(ctx)
+
+	req := signedRequest{method: method, headers: headers, md: md}
+	for _, v := range []struct {
+		name  string
+		value *string
+	}{
+		{signatureDateHeader, &req.date},
+		{signatureNonceHeader, &req.nonce},
+		{signatureContentHeader, &req.content},
+	} {
+		values := md.Get(v.name)
+		if len(values) != 1 || values[0] == "" {
+			return SigningKey{}, signedRequest{}, fmt.Errorf("%w: exactly one %s header is required",
+				ErrInvalidCredentials, v.name)
+		}
+
+		*v.value = values[0]
+	}
+
+	now := a.cfg.Now()
+
+	date, err := time.Parse(signatureDateFormat, req.date)
+	if err != nil || date.Before(now.Add(-a.cfg.Window)) || date.After(now.Add(a.cfg.Window)) {
+		return SigningKey{}, signedRequest{}, fmt.Errorf("%w: request date outside of the window",
+			ErrInvalidCredentials)
+	}
+
+	key, err := a.cfg.Keyring.SigningKey(ctx, id)
+
+	switch {
+	case errors.Is(err, ErrSigningKeyNotFound):
+		return SigningKey{}, signedRequest{}, fmt.Errorf("%w: unknown signing key", ErrInvalidCredentials)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return SigningKey{}, signedRequest{}, err
+	case err != nil:
+		return SigningKey{}, signedRequest{}, fmt.Errorf("%w: unable to retrieve signing key: %w",
+			ErrVerifierUnavailable, err)
+	}
+
+	if !key.NotAfter.IsZero() && now.After(key.NotAfter) {
+		return SigningKey{}, signedRequest{}, fmt.Errorf("%w: signing key expired", ErrInvalidCredentials)
+	}
+
+	if !hmac.Equal(sig, req.sign(key.Secret)) {
+		return SigningKey{}, signedRequest{}, fmt.Errorf("%w: signature mismatch", ErrInvalidCredentials)
+	}
+
+	if !a.replay.remember(now, key.ID, req.nonce) {
+		return SigningKey{}, signedRequest{}, fmt.Errorf("%w: nonce replayed", ErrInvalidCredentials)
+	}
+
+	return key, req, nil
+}
+
+// SignedContentUnaryServerInterceptor returns an interceptor that checks the request message matches the hash
+// signed by a RequestSigner, it must be installed after the authentication interceptor.
+//
+// Requests authenticated with other schemes are passed through unchanged.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func SignedContentUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		p, ok := PrincipalFromContext(ctx)
+		if !ok || p.Scheme != SignatureScheme {
+			return handler(ctx, req)
+		}
+
+		signed, _ := p.Claim(signatureContentClaim)
+
+		sum, err := signatureContentHash(req)
+		if err != nil || signed != sum {
+			return nil, status.Error(codes.Unauthenticated, "request message does not match signature")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// signedRequest is the canonical form of a request for signing.
+type signedRequest struct {
+	method  string
+	date    string
+	nonce   string
+	content string
+	headers []string
+	md      metadata.MD
+}
+
+// sign returns the HMAC-SHA256 of the canonical request.
+func (r signedRequest) sign(secret []byte) []byte {
+	var b strings.Builder
+
+	b.WriteString(SignatureScheme + "\n")
+	b.WriteString(r.method + "\n")
+	b.WriteString(r.date + "\n")
+	b.WriteString(r.nonce + "\n")
+	b.WriteString(strings.Join(r.headers, ";") + "\n")
+
+	for _, h := range r.headers {
+		b.WriteString(h + ":" + strings.Join(r.md.Get(h), ",") + "\n")
+	}
+
+	b.WriteString(r.content)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(b.String()))
+
+	return mac.Sum(nil)
+}
+
+// parseSignatureCredentials parses "Credential=<id>, SignedHeaders=<h1;h2>, Signature=<hex>".
+func parseSignatureCredentials(credentials string) (string, []string, []byte, error) {
+	params := map[string]string{}
+
+	for _, part := range strings.Split(credentials, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return "", nil, nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+		}
+
+		params[k] = v
+	}
+
+	sig, err := hex.DecodeString(params["Signature"])
+	if err != nil || params["Credential"] == "" || len(sig) != sha256.Size {
+		return "", nil, nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+
+	var headers []string
+	if v := params["SignedHeaders"]; v != "" {
+		headers = strings.Split(v, ";")
+	}
+
+	for _, h := range headers {
+		// pseudo-headers and the authorization header can not be signed.
+		if h == "" || h != strings.ToLower(h) || strings.HasPrefix(h, ":") || h == "authorization" {
+			return "", nil, nil, fmt.Errorf("%w: malformed signed headers", ErrInvalidCredentials)
+		}
+	}
+
+	return params["Credential"], headers, sig, nil
+}
+
+// signatureContentHash returns the hex SHA-256 of the deterministic protobuf serialization of the message.
+func signatureContentHash(msg any) (string, error) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return "", fmt.Errorf("unable to sign request: %T is not a protobuf message", msg)
+	}
+
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("unable to sign request: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,264 @@
+package grpcauth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testSigningMethod = "/grpcauth.test.Test/TestOnline"
+
+//nolint:gochecknoglobals // test code
+var (
+	testSigningKey    = grpcauth.SigningKey{ID: "key-1", Secret: []byte("secret-1"), Subject: "orders-service"}
+	testSigningKeyTwo = grpcauth.SigningKey{ID: "key-2", Secret: []byte("secret-2")}
+)
+
+// testSignedMetadata signs the request with the signer and returns the incoming metadata the server receives.
+func testSignedMetadata(t *testing.T, signer *grpcauth.RequestSigner, req any, out metadata.MD) metadata.MD {
+	t.Helper()
+
+	ctx := metadata.NewOutgoingContext(context.Background(), out)
+
+	var callCtx context.Context
+	if req != nil {
+		if err := signer.UnaryClientInterceptor()(ctx, testSigningMethod, req, nil, nil, func(
+			ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption,
+		) error {
+			callCtx = ctx
+
+			return nil
+		}); err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+	} else {
+		callCtx = ctx
+	}
+
+	callCtx = credentials.NewContextWithRequestInfo(callCtx, credentials.RequestInfo{
+		Method: testSigningMethod,
+		AuthInfo: credentials.TLSInfo{
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		},
+	})
+
+	r, err := signer.GetRequestMetadata(callCtx)
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	return metadata.Join(out, metadata.New(r))
+}
+
+func testSignatureAuthFunc(
+	keyring grpcauth.SigningKeyring,
+	now time.Time,
+) func(context.Context) (context.Context, error) {
+	return grpcauth.VerifyAuthenticatorsFunc(grpcauth.NewSignatureAuthenticator(grpcauth.SignatureConfig{
+		Keyring: keyring,
+		Now:     func() time.Time { return now },
+	}))
+}
+
+func testSignedContent(ctx context.Context, req any) error {
+	_, err := grpcauth.SignedContentUnaryServerInterceptor()(ctx, req, &grpc.UnaryServerInfo{
+		FullMethod: testSigningMethod,
+	}, func(_ context.Context, _ any) (any, error) {
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func TestSignatureAuthenticator(t *testing.T) {
+	keys := grpcauth.NewSigningKeys(testSigningKey)
+	signer := grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{Keys: keys, Metadata: []string{"X-Tenant"}})
+	req := wrapperspb.String("hello")
+
+	md := testSignedMetadata(t, signer, req, metadata.Pairs("x-tenant", "acme"))
+
+	ctx, err := testSignatureAuthFunc(keys, time.Now())(incomingContext(testSigningMethod, md))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	p, ok := grpcauth.PrincipalFromContext(ctx)
+	if !ok || p.Subject != "orders-service" || p.Scheme != grpcauth.SignatureScheme {
+		t.Errorf("expected principal 'orders-service' with scheme '%s', received '%+v'", grpcauth.SignatureScheme, p)
+	}
+
+	if err := testSignedContent(ctx, req); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+
+	if err := testSignedContent(ctx, wrapperspb.String("goodbye")); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestSignatureAuthenticator_Invalid(t *testing.T) {
+	keys := grpcauth.NewSigningKeys(testSigningKey)
+	signer := grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{Keys: keys, Metadata: []string{"x-tenant"}})
+	req := wrapperspb.String("hello")
+
+	tampered := testSignedMetadata(t, signer, req, metadata.Pairs("x-tenant", "acme"))
+	tampered.Set("x-tenant", "other")
+
+	unknownKey := testSignedMetadata(t, grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{
+		Keys: grpcauth.NewSigningKeys(testSigningKeyTwo),
+	}), req, nil)
+
+	missingDate := testSignedMetadata(t, signer, req, nil)
+	missingDate.Delete("x-signature-date")
+
+	tests := []struct {
+		name   string
+		method string
+		md     metadata.MD
+		now    time.Time
+	}{
+		{"tampered metadata", testSigningMethod, tampered, time.Now()},
+		{"other method", "/grpcauth.test.Test/TestOffline", testSignedMetadata(t, signer, req, nil), time.Now()},
+		{"clock skew", testSigningMethod, testSignedMetadata(t, signer, req, nil), time.Now().Add(10 * time.Minute)},
+		{"unknown key", testSigningMethod, unknownKey, time.Now()},
+		{"missing date", testSigningMethod, missingDate, time.Now()},
+		{"malformed", testSigningMethod, metadata.Pairs("authorization", "GRPC-HMAC-SHA256 Credential"), time.Now()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := testSignatureAuthFunc(keys, tt.now)(incomingContext(tt.method, tt.md)); status.Code(
+				err,
+			) != codes.Unauthenticated {
+				t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestSignatureAuthenticator_Replay(t *testing.T) {
+	keys := grpcauth.NewSigningKeys(testSigningKey)
+	md := testSignedMetadata(t, grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{Keys: keys}),
+		wrapperspb.String("hello"), nil)
+	authFunc := testSignatureAuthFunc(keys, time.Now())
+
+	if _, err := authFunc(incomingContext(testSigningMethod, md)); err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if _, err := authFunc(incomingContext(testSigningMethod, md)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+type testSigningKeySource struct {
+	key grpcauth.SigningKey
+}
+
+func (s testSigningKeySource) Current(context.Context) (grpcauth.SigningKey, error) {
+	return s.key, nil
+}
+
+func TestSignatureAuthenticator_KeySource(t *testing.T) {
+	signer := grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{
+		Keys: testSigningKeySource{key: testSigningKeyTwo},
+	})
+	md := testSignedMetadata(t, signer, wrapperspb.String("hello"), nil)
+
+	keyring := grpcauth.SigningKeyringFunc(func(_ context.Context, id string) (grpcauth.SigningKey, error) {
+		if id != testSigningKeyTwo.ID {
+			return grpcauth.SigningKey{}, grpcauth.ErrSigningKeyNotFound
+		}
+
+		return testSigningKeyTwo, nil
+	})
+
+	if _, err := testSignatureAuthFunc(keyring, time.Now())(incomingContext(testSigningMethod, md)); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+}
+
+func TestSignatureAuthenticator_Rotation(t *testing.T) {
+	keys := grpcauth.NewSigningKeys(testSigningKey)
+	signer := grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{Keys: keys})
+	oldMD := testSignedMetadata(t, signer, wrapperspb.String("hello"), nil)
+
+	keys.Rotate(testSigningKeyTwo)
+	newMD := testSignedMetadata(t, signer, wrapperspb.String("hello"), nil)
+
+	authFunc := testSignatureAuthFunc(keys, time.Now())
+
+	ctx, err := authFunc(incomingContext(testSigningMethod, newMD))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if p, _ := grpcauth.PrincipalFromContext(ctx); p.Subject != "key-2" {
+		t.Errorf("expected subject to be '%s', received '%s'", "key-2", p.Subject)
+	}
+
+	keys.Remove(testSigningKey.ID)
+
+	if _, err := authFunc(incomingContext(testSigningMethod, oldMD)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestSignatureAuthenticator_ExpiredKey(t *testing.T) {
+	expired := testSigningKey
+	expired.NotAfter = time.Now().Add(-time.Minute)
+
+	keys := grpcauth.NewSigningKeys(expired)
+	md := testSignedMetadata(t, grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{Keys: keys}),
+		wrapperspb.String("hello"), nil)
+
+	if _, err := testSignatureAuthFunc(keys, time.Now())(incomingContext(testSigningMethod, md)); status.Code(
+		err,
+	) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestSignatureAuthenticator_KeyringUnavailable(t *testing.T) {
+	keys := grpcauth.NewSigningKeys(testSigningKey)
+	md := testSignedMetadata(t, grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{Keys: keys}),
+		wrapperspb.String("hello"), nil)
+
+	keyring := grpcauth.SigningKeyringFunc(func(context.Context, string) (grpcauth.SigningKey, error) {
+		return grpcauth.SigningKey{}, errors.New("connection refused")
+	})
+
+	if _, err := testSignatureAuthFunc(keyring, time.Now())(incomingContext(testSigningMethod, md)); status.Code(
+		err,
+	) != codes.Unavailable {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unavailable, status.Code(err))
+	}
+}
+
+func TestSignedContentUnaryServerInterceptor_UnsignedPayload(t *testing.T) {
+	keys := grpcauth.NewSigningKeys(testSigningKey)
+	md := testSignedMetadata(t, grpcauth.NewRequestSigner(grpcauth.RequestSignerConfig{Keys: keys}), nil, nil)
+
+	ctx, err := testSignatureAuthFunc(keys, time.Now())(incomingContext(testSigningMethod, md))
+	if err != nil {
+		t.Fatalf("expected error to be nil, returned '%v'", err)
+	}
+
+	if err := testSignedContent(ctx, wrapperspb.String("hello")); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+
+	// requests authenticated with other schemes are passed through.
+	if err := testSignedContent(context.Background(), wrapperspb.String("hello")); err != nil {
+		t.Errorf("expected error to be nil, returned '%v'", err)
+	}
+}
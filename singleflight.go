@@ -0,0 +1,154 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+)
+
+// DeduplicateBearerFunc wraps the bearer verification function so concurrent verifications of the same token
+// share a single call, the result is returned to every caller that is waiting.
+//
+// Each caller stops waiting when its context is done, the shared verification is cancelled once no callers
+// remain. Context values other than the principal that were added by the verifier are not shared.
+//
+// The shared verification runs with the context of the caller that started it, so verify must only depend on the
+// token. Checks bound to the connection or request (eg. CertificateBoundFunc, ChannelBindingFunc or verifiers that
+// read the peer or metadata) must wrap the deduplicated function rather than be wrapped by it.
+func DeduplicateBearerFunc(verify AuthVerifyBearerErrFunc) AuthVerifyBearerErrFunc {
+	g := newFlightGroup()
+
+	return func(ctx context.Context, token string) (context.Context, string, bool, error) {
+		return g.do(ctx, g.hasher.sum(token), func(callCtx context.Context) (context.Context, string, bool, error) {
+			return verify(callCtx, token)
+		})
+	}
+}
+
+// DeduplicateBasicFunc wraps the basic verification function so concurrent verifications of the same username
+// and password share a single call, the result is returned to every caller that is waiting.
+//
+// Each caller stops waiting when its context is done, the shared verification is cancelled once no callers
+// remain. Context values other than the principal that were added by the verifier are not shared.
+//
+// The shared verification runs with the context of the caller that started it, so verify must only depend on the
+// username and password. Checks bound to the connection or request (eg. verifiers that read the peer or metadata)
+// must wrap the deduplicated function rather than be wrapped by it.
+func DeduplicateBasicFunc(verify AuthVerifyBasicErrFunc) AuthVerifyBasicErrFunc {
+	g := newFlightGroup()
+
+	return func(ctx context.Context, u, p string) (context.Context, string, error) {
+		outCtx, user, _, err := g.do(
+			ctx,
+			g.hasher.sum(u, p),
+			func(callCtx context.Context) (context.Context, string, bool, error) {
+				outCtx, user, err := verify(callCtx, u, p)
+
+				return outCtx, user, true, err
+			},
+		)
+
+		return outCtx, user, err
+	}
+}
+
+type flightGroup struct {
+	hasher *credentialHasher
+	mu     sync.Mutex
+	calls  map[[sha256.Size]byte]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	waiters int
+	cancel  context.CancelFunc
+
+	user      string
+	online    bool
+	principal *Principal
+	err       error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{
+		hasher: newCredentialHasher(),
+		calls:  map[[sha256.Size]byte]*flightCall{},
+	}
+}
+
+func (g *flightGroup) do(
+	ctx context.Context,
+	key [sha256.Size]byte,
+	fn func(context.Context) (context.Context, string, bool, error),
+) (context.Context, string, bool, error) {
+	g.mu.Lock()
+
+	c, ok := g.calls[key]
+	if !ok {
+		var callCtx context.Context
+
+		// the shared call must not be cancelled by the caller that started it.
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+
+		go g.run(callCtx, key, c, fn)
+	}
+
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+
+		// abandon the shared call, later callers start a new one.
+		if c.waiters == 0 {
+			c.cancel()
+
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+
+		return ctx, "", false, ctx.Err()
+	}
+
+	if c.err != nil {
+		return ctx, "", false, c.err
+	}
+
+	if c.principal != nil {
+		p := *c.principal
+		ctx = NewContextWithPrincipal(ctx, &p)
+	}
+
+	return ctx, c.user, c.online, nil
+}
+
+func (g *flightGroup) run(
+	callCtx context.Context,
+	key [sha256.Size]byte,
+	c *flightCall,
+	fn func(context.Context) (context.Context, string, bool, error),
+) {
+	defer c.cancel()
+
+	outCtx, user, online, err := fn(callCtx)
+
+	c.user, c.online, c.err = user, online, err
+	if p, ok := PrincipalFromContext(outCtx); ok && err == nil {
+		c.principal = p
+	}
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+
+	close(c.done)
+}
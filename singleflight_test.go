@@ -0,0 +1,256 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dosquad/go-grpcauth"
+)
+
+type blockingBearerVerifier struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (v *blockingBearerVerifier) VerifyBearer(ctx context.Context, token string) (context.Context, string, bool, error) {
+	v.calls.Add(1)
+
+	select {
+	case <-v.release:
+	case <-ctx.Done():
+		return ctx, "", false, ctx.Err()
+	}
+
+	if token == "invalid-token" {
+		return ctx, "", false, grpcauth.ErrInvalidCredentials
+	}
+
+	return grpcauth.NewContextWithPrincipal(ctx, &grpcauth.Principal{Scopes: []string{"read"}}), "user-" + token, true, nil
+}
+
+func waitForCalls(t *testing.T, calls *atomic.Int32, expected int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < expected && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeduplicateBearerFunc_Concurrent(t *testing.T) {
+	verifier := &blockingBearerVerifier{release: make(chan struct{})}
+	verify := grpcauth.DeduplicateBearerFunc(verifier.VerifyBearer)
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+	)
+
+	for range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ctx, user, online, err := verify(context.Background(), "token")
+			p, _ := grpcauth.PrincipalFromContext(ctx)
+
+			if err != nil || user != "user-token" || !online || !p.HasScope("read") {
+				failed.Add(1)
+			}
+		}()
+	}
+
+	waitForCalls(t, &verifier.calls, 1)
+	time.Sleep(10 * time.Millisecond)
+	close(verifier.release)
+	wg.Wait()
+
+	if f := failed.Load(); f != 0 {
+		t.Errorf("expected all verifications to succeed, '%d' failed", f)
+	}
+
+	if c := verifier.calls.Load(); c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+}
+
+func TestDeduplicateBearerFunc_DifferentTokens(t *testing.T) {
+	verifier := &blockingBearerVerifier{release: make(chan struct{})}
+	close(verifier.release)
+
+	verify := grpcauth.DeduplicateBearerFunc(verifier.VerifyBearer)
+
+	if _, user, _, _ := verify(context.Background(), "a"); user != "user-a" {
+		t.Errorf("expected user to be '%s', received '%s'", "user-a", user)
+	}
+
+	if _, _, _, err := verify(context.Background(), "invalid-token"); !errors.Is(err, grpcauth.ErrInvalidCredentials) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, err)
+	}
+
+	if c := verifier.calls.Load(); c != 2 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 2, c)
+	}
+}
+
+func TestDeduplicateBearerFunc_ConnectionBoundOutside(t *testing.T) {
+	cert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "bound-client"}})
+	otherCert := testClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other-client"}})
+
+	token := signTestJWT(t, grpcauth.JWTAlgHS256, testHMACKey, map[string]any{"kid": "hmac"}, testJWTClaims(
+		map[string]any{"cnf": map[string]any{"x5t#S256": grpcauth.CertificateThumbprint(cert)}},
+	))
+
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+	jwtVerifier := testJWTVerifier()
+
+	// the binding must be checked outside of the shared call, so it uses the connection of each caller.
+	verify := grpcauth.CertificateBoundFunc(grpcauth.DeduplicateBearerFunc(
+		func(ctx context.Context, token string) (context.Context, string, bool, error) {
+			calls.Add(1)
+			<-release
+
+			return jwtVerifier.VerifyBearer(ctx, token)
+		},
+	), grpcauth.CertificateBoundConfig{})
+
+	var (
+		wg   sync.WaitGroup
+		errs [2]error
+	)
+
+	for i, c := range []*x509.Certificate{otherCert, cert} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, _, errs[i] = verify(peerContext(context.Background(), c, true), token)
+		}()
+
+		// the caller with the other certificate starts the shared call.
+		waitForCalls(t, &calls, 1)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if !errors.Is(errs[0], grpcauth.ErrInvalidCredentials) {
+		t.Errorf("expected error to be '%v', returned '%v'", grpcauth.ErrInvalidCredentials, errs[0])
+	}
+
+	if errs[1] != nil {
+		t.Errorf("expected error to be nil, returned '%v'", errs[1])
+	}
+
+	if c := calls.Load(); c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+}
+
+func TestDeduplicateBearerFunc_CallerCancellation(t *testing.T) {
+	verifier := &blockingBearerVerifier{release: make(chan struct{})}
+	verify := grpcauth.DeduplicateBearerFunc(verifier.VerifyBearer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		wg        sync.WaitGroup
+		cancelErr error
+		user      string
+	)
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		_, _, _, cancelErr = verify(ctx, "token")
+	}()
+
+	waitForCalls(t, &verifier.calls, 1)
+
+	go func() {
+		defer wg.Done()
+
+		_, user, _, _ = verify(context.Background(), "token")
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(verifier.release)
+	wg.Wait()
+
+	if !errors.Is(cancelErr, context.Canceled) {
+		t.Errorf("expected error to be '%v', returned '%v'", context.Canceled, cancelErr)
+	}
+
+	if user != "user-token" {
+		t.Errorf("expected user to be '%s', received '%s'", "user-token", user)
+	}
+
+	if c := verifier.calls.Load(); c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+}
+
+func TestDeduplicateBearerFunc_AllCallersCancelled(t *testing.T) {
+	verifier := &blockingBearerVerifier{release: make(chan struct{})}
+	verify := grpcauth.DeduplicateBearerFunc(verifier.VerifyBearer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, _, _, err := verify(ctx, "token"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to be '%v', returned '%v'", context.DeadlineExceeded, err)
+	}
+
+	close(verifier.release)
+
+	if _, user, _, err := verify(context.Background(), "token"); err != nil || user != "user-token" {
+		t.Errorf("expected new verification to succeed, received '%s' '%v'", user, err)
+	}
+}
+
+func TestDeduplicateBasicFunc(t *testing.T) {
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+	verify := grpcauth.DeduplicateBasicFunc(func(ctx context.Context, u, _ string) (context.Context, string, error) {
+		calls.Add(1)
+		<-release
+
+		return ctx, u, nil
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, _ = verify(context.Background(), "user", "pass")
+		}()
+	}
+
+	waitForCalls(t, &calls, 1)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if c := calls.Load(); c != 1 {
+		t.Errorf("expected verifier calls to be '%d', received '%d'", 1, c)
+	}
+}
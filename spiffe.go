@@ -0,0 +1,172 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SPIFFEScheme is the Principal scheme for SPIFFE X.509 SVID authentication.
+const SPIFFEScheme = "SPIFFE"
+
+const (
+	spiffeIDPrefix         = "spiffe://"
+	spiffeIDMaxLength      = 2048
+	spiffeTrustDomainChars = "abcdefghijklmnopqrstuvwxyz0123456789.-_"
+	spiffePathChars        = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.-_"
+	spiffeIDClaim          = "spiffe_id"
+)
+
+// ErrSPIFFEIDInvalid is returned when a SPIFFE ID is malformed or a certificate does not contain exactly one
+// SPIFFE ID.
+var ErrSPIFFEIDInvalid = errors.New("invalid SPIFFE ID")
+
+// SPIFFEID is a parsed SPIFFE ID (eg. "spiffe://example.org/ns/prod/sa/orders").
+type SPIFFEID struct {
+	// TrustDomain is the trust domain name (eg. "example.org").
+	TrustDomain string
+
+	// Path is the path including the leading slash (eg. "/ns/prod/sa/orders"), it is empty for the trust domain ID.
+	Path string
+}
+
+// String returns the SPIFFE ID URI.
+func (id SPIFFEID) String() string {
+	return spiffeIDPrefix + id.TrustDomain + id.Path
+}
+
+// Matches returns true if the SPIFFE ID matches the pattern, patterns use the syntax of path.Match
+// (eg. "spiffe://example.org/ns/*/sa/orders").
+func (id SPIFFEID) Matches(pattern string) bool {
+	ok, _ := path.Match(pattern, id.String())
+
+	return ok
+}
+
+// ParseSPIFFEID parses and validates a SPIFFE ID.
+func ParseSPIFFEID(s string) (SPIFFEID, error) {
+	if len(s) > spiffeIDMaxLength {
+		return SPIFFEID{}, fmt.Errorf("%w: too long", ErrSPIFFEIDInvalid)
+	}
+
+	rest, ok := strings.CutPrefix(s, spiffeIDPrefix)
+	if !ok {
+		return SPIFFEID{}, fmt.Errorf("%w: scheme must be spiffe", ErrSPIFFEIDInvalid)
+	}
+
+	td, p := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		td, p = rest[:i], rest[i:]
+	}
+
+	if td == "" || strings.Trim(td, spiffeTrustDomainChars) != "" {
+		return SPIFFEID{}, fmt.Errorf("%w: invalid trust domain '%s'", ErrSPIFFEIDInvalid, td)
+	}
+
+	if p != "" {
+		for _, segment := range strings.Split(p[1:], "/") {
+			if segment == "" || segment == "." || segment == ".." || strings.Trim(segment, spiffePathChars) != "" {
+				return SPIFFEID{}, fmt.Errorf("%w: invalid path '%s'", ErrSPIFFEIDInvalid, p)
+			}
+		}
+	}
+
+	return SPIFFEID{TrustDomain: td, Path: p}, nil
+}
+
+// SPIFFEIDFromCertificate returns the SPIFFE ID of an X.509 SVID, the certificate must have exactly one URI
+// subject alternative name.
+func SPIFFEIDFromCertificate(cert *x509.Certificate) (SPIFFEID, error) {
+	if len(cert.URIs) != 1 {
+		return SPIFFEID{}, fmt.Errorf("%w: certificate must have exactly one URI SAN", ErrSPIFFEIDInvalid)
+	}
+
+	return ParseSPIFFEID(cert.URIs[0].String())
+}
+
+// SPIFFEIDFromContext returns the SPIFFE ID of the principal authenticated by a SPIFFE authenticator and if it was
+// present, the client certificate principal is checked first.
+func SPIFFEIDFromContext(ctx context.Context) (SPIFFEID, bool) {
+	for _, fromContext := range []func(context.Context) (*Principal, bool){
+		ClientCertPrincipalFromContext,
+		PrincipalFromContext,
+	} {
+		if p, ok := fromContext(ctx); ok {
+			if v, ok := p.Claim(spiffeIDClaim); ok {
+				id, ok := v.(SPIFFEID)
+
+				return id, ok
+			}
+		}
+	}
+
+	return SPIFFEID{}, false
+}
+
+// SPIFFEConfig is the configuration for NewSPIFFEAuthenticator.
+type SPIFFEConfig struct {
+	// TrustDomains are the trust domains accepted, no SPIFFE IDs are accepted when empty.
+	TrustDomains []string
+
+	// Paths are the path patterns accepted (eg. "/ns/prod/sa/*"), patterns use the syntax of path.Match. Any path
+	// is accepted when empty.
+	Paths []string
+}
+
+// NewSPIFFEAuthenticator returns a ClientCertAuthenticator that authenticates X.509 SVIDs.
+//
+// The principal subject is the SPIFFE ID, it is also available from SPIFFEIDFromContext.
+func NewSPIFFEAuthenticator(cfg SPIFFEConfig) *ClientCertAuthenticator {
+	return &ClientCertAuthenticator{
+		scheme: SPIFFEScheme,
+		mapCert: func(cert *x509.Certificate) (*Principal, bool) {
+			id, err := SPIFFEIDFromCertificate(cert)
+			if err != nil || !slices.Contains(cfg.TrustDomains, id.TrustDomain) {
+				return nil, false
+			}
+
+			if len(cfg.Paths) > 0 && !slices.ContainsFunc(cfg.Paths, func(pattern string) bool {
+				ok, _ := path.Match(pattern, id.Path)
+
+				return ok
+			}) {
+				return nil, false
+			}
+
+			return &Principal{
+				Subject: id.String(),
+				Claims:  map[string]any{spiffeIDClaim: id},
+			}, true
+		},
+	}
+}
+
+// AuthorizeSPIFFEIDFunc wraps the authentication function, requests are only allowed when the authenticated
+// SPIFFE ID matches one of the patterns (see SPIFFEID.Matches), otherwise codes.PermissionDenied is returned.
+//
+//nolint:wrapcheck // status errors are returned to the client.
+func AuthorizeSPIFFEIDFunc(
+	authFunc func(ctx context.Context) (context.Context, error),
+	patterns ...string,
+) func(ctx context.Context) (context.Context, error) {
+	return func(ctx context.Context) (context.Context, error) {
+		outCtx, err := authFunc(ctx)
+		if err != nil {
+			return outCtx, err
+		}
+
+		id, ok := SPIFFEIDFromContext(outCtx)
+		if !ok || !slices.ContainsFunc(patterns, id.Matches) {
+			return ctx, status.Error(codes.PermissionDenied, "SPIFFE ID is not authorized")
+		}
+
+		return outCtx, nil
+	}
+}
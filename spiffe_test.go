@@ -0,0 +1,158 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/dosquad/go-grpcauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testSVID(t *testing.T, ids ...string) *x509.Certificate {
+	t.Helper()
+
+	tmpl := &x509.Certificate{}
+
+	for _, id := range ids {
+		u, err := url.Parse(id)
+		if err != nil {
+			t.Fatalf("expected error to be nil, returned '%v'", err)
+		}
+
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+
+	return testClientCert(t, tmpl)
+}
+
+func TestParseSPIFFEID(t *testing.T) {
+	tests := []struct {
+		id, expectedTrustDomain, expectedPath string
+		expectedErr                           error
+	}{
+		{"spiffe://example.org/ns/prod/sa/orders", "example.org", "/ns/prod/sa/orders", nil},
+		{"spiffe://example.org", "example.org", "", nil},
+		{"https://example.org/ns/prod", "", "", grpcauth.ErrSPIFFEIDInvalid},
+		{"spiffe://Example.org/ns", "", "", grpcauth.ErrSPIFFEIDInvalid},
+		{"spiffe://example.org:443/ns", "", "", grpcauth.ErrSPIFFEIDInvalid},
+		{"spiffe:///ns", "", "", grpcauth.ErrSPIFFEIDInvalid},
+		{"spiffe://example.org/ns/", "", "", grpcauth.ErrSPIFFEIDInvalid},
+		{"spiffe://example.org/ns//sa", "", "", grpcauth.ErrSPIFFEIDInvalid},
+		{"spiffe://example.org/ns/../sa", "", "", grpcauth.ErrSPIFFEIDInvalid},
+		{"spiffe://example.org/ns?query", "", "", grpcauth.ErrSPIFFEIDInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			id, err := grpcauth.ParseSPIFFEID(tt.id)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error to be '%v', returned '%v'", tt.expectedErr, err)
+			}
+
+			if id.TrustDomain != tt.expectedTrustDomain || id.Path != tt.expectedPath {
+				t.Errorf(
+					"expected trust domain and path to be '%s' '%s', received '%s' '%s'",
+					tt.expectedTrustDomain, tt.expectedPath, id.TrustDomain, id.Path,
+				)
+			}
+
+			if err == nil && id.String() != tt.id {
+				t.Errorf("expected string to be '%s', received '%s'", tt.id, id.String())
+			}
+		})
+	}
+}
+
+func TestSPIFFEAuthenticator_Verify(t *testing.T) {
+	tests := []struct {
+		name         string
+		ids          []string
+		expectedCode codes.Code
+	}{
+		{"valid", []string{"spiffe://example.org/ns/prod/sa/orders"}, codes.OK},
+		{"other trust domain", []string{"spiffe://other.org/ns/prod/sa/orders"}, codes.Unauthenticated},
+		{"other path", []string{"spiffe://example.org/ns/dev/sa/orders"}, codes.Unauthenticated},
+		{
+			"multiple URIs",
+			[]string{"spiffe://example.org/ns/prod/sa/orders", "spiffe://example.org/ns/prod/sa/billing"},
+			codes.Unauthenticated,
+		},
+		{"not a SPIFFE ID", []string{"https://example.org/ns/prod/sa/orders"}, codes.Unauthenticated},
+	}
+
+	a := grpcauth.NewSPIFFEAuthenticator(grpcauth.SPIFFEConfig{
+		TrustDomains: []string{"example.org"},
+		Paths:        []string{"/ns/prod/sa/*"},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authCtx, err := a.Verify(peerContext(context.Background(), testSVID(t, tt.ids...), true))
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Fatalf("expected status code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+
+			if tt.expectedCode != codes.OK {
+				return
+			}
+
+			p, _ := grpcauth.PrincipalFromContext(authCtx)
+			if p.Subject != tt.ids[0] || p.Scheme != grpcauth.SPIFFEScheme {
+				t.Errorf("expected principal '%s' with scheme '%s', received '%+v'", tt.ids[0], grpcauth.SPIFFEScheme, p)
+			}
+
+			if id, ok := grpcauth.SPIFFEIDFromContext(authCtx); !ok || id.Path != "/ns/prod/sa/orders" {
+				t.Errorf("expected SPIFFE ID path to be '%s', received '%+v'", "/ns/prod/sa/orders", id)
+			}
+		})
+	}
+}
+
+func TestSPIFFEAuthenticator_NoTrustDomains(t *testing.T) {
+	a := grpcauth.NewSPIFFEAuthenticator(grpcauth.SPIFFEConfig{})
+
+	ctx := peerContext(context.Background(), testSVID(t, "spiffe://example.org/ns/prod/sa/orders"), true)
+	if _, err := a.Verify(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestAuthorizeSPIFFEIDFunc(t *testing.T) {
+	a := grpcauth.NewSPIFFEAuthenticator(grpcauth.SPIFFEConfig{TrustDomains: []string{"example.org"}})
+	authFunc := grpcauth.AuthorizeSPIFFEIDFunc(a.Verify, "spiffe://example.org/ns/*/sa/orders")
+
+	tests := []struct {
+		id           string
+		expectedCode codes.Code
+	}{
+		{"spiffe://example.org/ns/prod/sa/orders", codes.OK},
+		{"spiffe://example.org/ns/dev/sa/orders", codes.OK},
+		{"spiffe://example.org/ns/prod/sa/billing", codes.PermissionDenied},
+		{"spiffe://other.org/ns/prod/sa/orders", codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			_, err := authFunc(peerContext(context.Background(), testSVID(t, tt.id), true))
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Errorf("expected status code to be '%s', received '%s'", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestAuthorizeSPIFFEIDFunc_NotSPIFFE(t *testing.T) {
+	authFunc := grpcauth.AuthorizeSPIFFEIDFunc(
+		testClientCertAuthenticator().Verify,
+		"spiffe://example.org/*",
+	)
+
+	ctx := peerContext(context.Background(), testSVID(t, "https://services.example.com/orders"), true)
+	if _, err := authFunc(ctx); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected status code to be '%s', received '%s'", codes.PermissionDenied, status.Code(err))
+	}
+}
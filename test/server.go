@@ -21,14 +21,14 @@ func NewServer(
 		return nil, nil
 	}
 
+	policy := grpcauth.NewRulePolicy(grpcauth.RulePolicyConfig{})
+
+	authFunc := grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc)
+
 	opts = append(
 		opts,
-		grpc.StreamInterceptor(
-			grpcauth.StreamServerInterceptor(grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc)),
-		),
-		grpc.UnaryInterceptor(
-			grpcauth.UnaryServerInterceptor(grpcauth.VerifyAuthorizationFunc(basicAuthFunc, bearerAuthFunc)),
-		),
+		grpc.StreamInterceptor(policy.StreamServerInterceptor(authFunc)),
+		grpc.UnaryInterceptor(policy.UnaryServerInterceptor(authFunc)),
 	)
 	grpcServer := grpc.NewServer(opts...)
 	RegisterTestServer(grpcServer, &testServer{})
@@ -43,40 +43,32 @@ func NewServer(
 
 type testServer struct{}
 
+// TestOnline requires an online token, this is enforced by the (grpcauth.rule) option in
+// simple.proto.
 func (t *testServer) TestOnline(ctx context.Context, _ *EmptyRequest) (*Response, error) {
-	if v, ok := ctx.Value(grpcauth.Online).(bool); ok && v {
-		user := ""
-		if u, userOk := ctx.Value(grpcauth.Username).(string); userOk {
-			user = u
-		}
-
-		return &Response{
-			User:   user,
-			Online: v,
-		}, nil
+	p, ok := grpcauth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
 	}
 
-	return nil, status.Error(codes.Unauthenticated, "request requires an online token")
+	return &Response{
+		User:   p.Subject,
+		Online: p.Online,
+	}, nil
 }
 
 func (t *testServer) TestOffline(ctx context.Context, _ *EmptyRequest) (*Response, error) {
-	online := false
-	user := ""
-
-	if v, ok := ctx.Value(grpcauth.Online).(bool); ok {
-		online = v
-	}
-
-	if u, ok := ctx.Value(grpcauth.Username).(string); ok {
-		user = u
+	p, ok := grpcauth.PrincipalFromContext(ctx)
+	if !ok {
+		p = &grpcauth.Principal{}
 	}
 
-	if online {
+	if p.Online {
 		return nil, status.Error(codes.Unauthenticated, "request requires an offline token")
 	}
 
 	return &Response{
-		User:   user,
-		Online: online,
+		User:   p.Subject,
+		Online: p.Online,
 	}, nil
 }
@@ -7,6 +7,7 @@
 package test
 
 import (
+	_ "github.com/dosquad/go-grpcauth"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -109,19 +110,286 @@ func (x *Response) GetOnline() bool {
 	return false
 }
 
+// OwnedRequest is used to test request field ownership.
+type OwnedRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	User           string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Tenants        []string               `protobuf:"bytes,2,rep,name=tenants,proto3" json:"tenants,omitempty"`
+	Account        *Account               `protobuf:"bytes,3,opt,name=account,proto3" json:"account,omitempty"`
+	Accounts       []*Account             `protobuf:"bytes,4,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	AccountsByName map[string]*Account    `protobuf:"bytes,5,rep,name=accounts_by_name,json=accountsByName,proto3" json:"accounts_by_name,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *OwnedRequest) Reset() {
+	*x = OwnedRequest{}
+	mi := &file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OwnedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OwnedRequest) ProtoMessage() {}
+
+func (x *OwnedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OwnedRequest.ProtoReflect.Descriptor instead.
+func (*OwnedRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OwnedRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *OwnedRequest) GetTenants() []string {
+	if x != nil {
+		return x.Tenants
+	}
+	return nil
+}
+
+func (x *OwnedRequest) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+func (x *OwnedRequest) GetAccounts() []*Account {
+	if x != nil {
+		return x.Accounts
+	}
+	return nil
+}
+
+func (x *OwnedRequest) GetAccountsByName() map[string]*Account {
+	if x != nil {
+		return x.AccountsByName
+	}
+	return nil
+}
+
+type Account struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Account) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Account) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Profile is used to test response field redaction.
+type Profile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Salary        int64                  `protobuf:"varint,3,opt,name=salary,proto3" json:"salary,omitempty"`
+	Phones        []string               `protobuf:"bytes,4,rep,name=phones,proto3" json:"phones,omitempty"`
+	Notes         map[string]string      `protobuf:"bytes,5,rep,name=notes,proto3" json:"notes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Manager       *Profile               `protobuf:"bytes,6,opt,name=manager,proto3" json:"manager,omitempty"`
+	Reports       []*Profile             `protobuf:"bytes,7,rep,name=reports,proto3" json:"reports,omitempty"`
+	Peers         map[string]*Profile    `protobuf:"bytes,8,rep,name=peers,proto3" json:"peers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Profile) Reset() {
+	*x = Profile{}
+	mi := &file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Profile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Profile) ProtoMessage() {}
+
+func (x *Profile) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Profile.ProtoReflect.Descriptor instead.
+func (*Profile) Descriptor() ([]byte, []int) {
+	return file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Profile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Profile) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Profile) GetSalary() int64 {
+	if x != nil {
+		return x.Salary
+	}
+	return 0
+}
+
+func (x *Profile) GetPhones() []string {
+	if x != nil {
+		return x.Phones
+	}
+	return nil
+}
+
+func (x *Profile) GetNotes() map[string]string {
+	if x != nil {
+		return x.Notes
+	}
+	return nil
+}
+
+func (x *Profile) GetManager() *Profile {
+	if x != nil {
+		return x.Manager
+	}
+	return nil
+}
+
+func (x *Profile) GetReports() []*Profile {
+	if x != nil {
+		return x.Reports
+	}
+	return nil
+}
+
+func (x *Profile) GetPeers() map[string]*Profile {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
 var File_github_com_dosquad_go_grpcauth_test_simple_proto protoreflect.FileDescriptor
 
 const file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDesc = "" +
 	"\n" +
-	"0github.com/dosquad/go-grpcauth/test/simple.proto\x12\rgrpcauth.test\"\x0e\n" +
+	"0github.com/dosquad/go-grpcauth/test/simple.proto\x12\rgrpcauth.test\x1a-github.com/dosquad/go-grpcauth/grpcauth.proto\"\x0e\n" +
 	"\fEmptyRequest\"6\n" +
 	"\bResponse\x12\x12\n" +
 	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
-	"\x06online\x18\x02 \x01(\bR\x06online2\x8f\x01\n" +
+	"\x06online\x18\x02 \x01(\bR\x06online\"\xec\x02\n" +
+	"\fOwnedRequest\x12\x18\n" +
+	"\x04user\x18\x01 \x01(\tB\x04\x9a\x82\x19\x00R\x04user\x12&\n" +
+	"\atenants\x18\x02 \x03(\tB\f\x9a\x82\x19\b\n" +
+	"\x06tenantR\atenants\x120\n" +
+	"\aaccount\x18\x03 \x01(\v2\x16.grpcauth.test.AccountR\aaccount\x122\n" +
+	"\baccounts\x18\x04 \x03(\v2\x16.grpcauth.test.AccountR\baccounts\x12Y\n" +
+	"\x10accounts_by_name\x18\x05 \x03(\v2/.grpcauth.test.OwnedRequest.AccountsByNameEntryR\x0eaccountsByName\x1aY\n" +
+	"\x13AccountsByNameEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.grpcauth.test.AccountR\x05value:\x028\x01\"=\n" +
+	"\aAccount\x12\x1e\n" +
+	"\x02id\x18\x01 \x01(\tB\x0e\x9a\x82\x19\n" +
+	"\n" +
+	"\baccountsR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\x97\x04\n" +
+	"\aProfile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x125\n" +
+	"\x05email\x18\x02 \x01(\tB\x1f\xa2\x82\x19\x1b\n" +
+	"\rprofile.email\n" +
+	"\x05admin\x12\x03***R\x05email\x12#\n" +
+	"\x06salary\x18\x03 \x01(\x03B\v\xa2\x82\x19\a\n" +
+	"\x05adminR\x06salary\x12(\n" +
+	"\x06phones\x18\x04 \x03(\tB\x10\xa2\x82\x19\f\n" +
+	"\x05admin\x12\x03***R\x06phones\x12I\n" +
+	"\x05notes\x18\x05 \x03(\v2!.grpcauth.test.Profile.NotesEntryB\x10\xa2\x82\x19\f\n" +
+	"\x05admin\x12\x03***R\x05notes\x120\n" +
+	"\amanager\x18\x06 \x01(\v2\x16.grpcauth.test.ProfileR\amanager\x120\n" +
+	"\areports\x18\a \x03(\v2\x16.grpcauth.test.ProfileR\areports\x127\n" +
+	"\x05peers\x18\b \x03(\v2!.grpcauth.test.Profile.PeersEntryR\x05peers\x1a8\n" +
+	"\n" +
+	"NotesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aP\n" +
+	"\n" +
+	"PeersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.grpcauth.test.ProfileR\x05value:\x028\x012\x97\x01\n" +
 	"\x04Test\x12C\n" +
-	"\vTestOffline\x12\x1b.grpcauth.test.EmptyRequest\x1a\x17.grpcauth.test.Response\x12B\n" +
+	"\vTestOffline\x12\x1b.grpcauth.test.EmptyRequest\x1a\x17.grpcauth.test.Response\x12J\n" +
 	"\n" +
-	"TestOnline\x12\x1b.grpcauth.test.EmptyRequest\x1a\x17.grpcauth.test.ResponseB%Z#github.com/dosquad/go-grpcauth/testb\x06proto3"
+	"TestOnline\x12\x1b.grpcauth.test.EmptyRequest\x1a\x17.grpcauth.test.Response\"\x06\x92\x82\x19\x02\x10\x01B%Z#github.com/dosquad/go-grpcauth/testb\x06proto3"
 
 var (
 	file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDescOnce sync.Once
@@ -135,21 +403,36 @@ func file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDescGZIP() []byte
 	return file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDescData
 }
 
-var file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_github_com_dosquad_go_grpcauth_test_simple_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_github_com_dosquad_go_grpcauth_test_simple_proto_goTypes = []any{
 	(*EmptyRequest)(nil), // 0: grpcauth.test.EmptyRequest
 	(*Response)(nil),     // 1: grpcauth.test.Response
+	(*OwnedRequest)(nil), // 2: grpcauth.test.OwnedRequest
+	(*Account)(nil),      // 3: grpcauth.test.Account
+	(*Profile)(nil),      // 4: grpcauth.test.Profile
+	nil,                  // 5: grpcauth.test.OwnedRequest.AccountsByNameEntry
+	nil,                  // 6: grpcauth.test.Profile.NotesEntry
+	nil,                  // 7: grpcauth.test.Profile.PeersEntry
 }
 var file_github_com_dosquad_go_grpcauth_test_simple_proto_depIdxs = []int32{
-	0, // 0: grpcauth.test.Test.TestOffline:input_type -> grpcauth.test.EmptyRequest
-	0, // 1: grpcauth.test.Test.TestOnline:input_type -> grpcauth.test.EmptyRequest
-	1, // 2: grpcauth.test.Test.TestOffline:output_type -> grpcauth.test.Response
-	1, // 3: grpcauth.test.Test.TestOnline:output_type -> grpcauth.test.Response
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3,  // 0: grpcauth.test.OwnedRequest.account:type_name -> grpcauth.test.Account
+	3,  // 1: grpcauth.test.OwnedRequest.accounts:type_name -> grpcauth.test.Account
+	5,  // 2: grpcauth.test.OwnedRequest.accounts_by_name:type_name -> grpcauth.test.OwnedRequest.AccountsByNameEntry
+	6,  // 3: grpcauth.test.Profile.notes:type_name -> grpcauth.test.Profile.NotesEntry
+	4,  // 4: grpcauth.test.Profile.manager:type_name -> grpcauth.test.Profile
+	4,  // 5: grpcauth.test.Profile.reports:type_name -> grpcauth.test.Profile
+	7,  // 6: grpcauth.test.Profile.peers:type_name -> grpcauth.test.Profile.PeersEntry
+	3,  // 7: grpcauth.test.OwnedRequest.AccountsByNameEntry.value:type_name -> grpcauth.test.Account
+	4,  // 8: grpcauth.test.Profile.PeersEntry.value:type_name -> grpcauth.test.Profile
+	0,  // 9: grpcauth.test.Test.TestOffline:input_type -> grpcauth.test.EmptyRequest
+	0,  // 10: grpcauth.test.Test.TestOnline:input_type -> grpcauth.test.EmptyRequest
+	1,  // 11: grpcauth.test.Test.TestOffline:output_type -> grpcauth.test.Response
+	1,  // 12: grpcauth.test.Test.TestOnline:output_type -> grpcauth.test.Response
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_github_com_dosquad_go_grpcauth_test_simple_proto_init() }
@@ -163,7 +446,7 @@ func file_github_com_dosquad_go_grpcauth_test_simple_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDesc), len(file_github_com_dosquad_go_grpcauth_test_simple_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
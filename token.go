@@ -2,6 +2,7 @@ package grpcauth
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 
 	"google.golang.org/grpc/credentials"
@@ -16,17 +17,30 @@ func NewTokenCredentials(token string) credentials.PerRPCCredentials {
 }
 
 type TokenCreds struct {
-	token string
+	token          string
+	channelBinding bool
 }
 
-// GetRequestMetadata adds the HTTP Authorization Bearer header to the request.
+// GetRequestMetadata adds the HTTP Authorization Bearer header to the request, and the channel binding header when
+// created with NewChannelBoundTokenCredentials.
 func (c *TokenCreds) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
 	ri, _ := credentials.RequestInfoFromContext(ctx)
 	if err := credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity); err != nil {
 		return nil, fmt.Errorf("unable to transfer Token PerRPCCredentials: %w", err)
 	}
 
-	return map[string]string{"Authorization": "Bearer " + c.token}, nil
+	md := map[string]string{"Authorization": "Bearer " + c.token}
+
+	if c.channelBinding {
+		ekm, err := tlsExporter(ri.AuthInfo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to transfer Token PerRPCCredentials: %w", err)
+		}
+
+		md[channelBindingHeader] = base64.RawURLEncoding.EncodeToString(channelBindingMAC(c.token, ekm))
+	}
+
+	return md, nil
 }
 
 // RequireTransportSecurity indicates whether the credentials requires
@@ -3,12 +3,11 @@ package grpcauth
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"regexp"
 	"strings"
 
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 )
 
 var re = regexp.MustCompile(`^(\S+)\s+(.*)$`)
@@ -23,13 +22,29 @@ type AuthVerifyBasicFunc = func(context.Context, string, string) (context.Contex
 // AuthVerifyBearerFunc is used to verify HTTP Bearer Authorization headers.
 type AuthVerifyBearerFunc = func(context.Context, string) (context.Context, string, bool, bool)
 
+// AuthVerifyBasicErrFunc is used to verify HTTP Basic Authorization headers, returning an error when the
+// credentials can not be verified.
+//
+// Invalid credentials should be reported by returning (or wrapping) ErrInvalidCredentials.
+type AuthVerifyBasicErrFunc = func(context.Context, string, string) (context.Context, string, error)
+
+// AuthVerifyBearerErrFunc is used to verify HTTP Bearer Authorization headers, returning an error when the
+// token can not be verified.
+//
+// Invalid tokens should be reported by returning (or wrapping) ErrInvalidCredentials.
+type AuthVerifyBearerErrFunc = func(context.Context, string) (context.Context, string, bool, error)
+
 type contextValue string
 
 const (
 	// Username is the context value of the username returned by the authentication verification function.
+	//
+	// PrincipalFromContext should be preferred, the value is kept for compatibility.
 	Username contextValue = "username"
 
 	// Online is the context value indicating if a authentication method was online or offline.
+	//
+	// PrincipalFromContext should be preferred, the value is kept for compatibility.
 	Online contextValue = "online"
 )
 
@@ -41,61 +56,73 @@ func getHeadersFromContext(ctx context.Context) []string {
 	return []string{}
 }
 
-//nolint:wrapcheck,mnd // expected set length based on format.
-func verifyAuthBasic(ctx context.Context, basicAuth AuthVerifyBasicFunc, encodedAuth string) (context.Context, error) {
+//nolint:mnd // expected set length based on format.
+func verifyAuthBasic(ctx context.Context, basicAuth AuthVerifyBasicErrFunc, encodedAuth string) (context.Context, error) {
 	bo, err := base64.StdEncoding.DecodeString(encodedAuth)
 	if err != nil {
-		return ctx, status.Error(codes.Unauthenticated, "authentication failed with Basic authorization scheme")
+		return ctx, verifierError("Basic", ErrInvalidCredentials)
 	}
 
 	authString := strings.SplitN(string(bo), ":", 2)
 	if len(authString) != 2 {
-		return ctx, status.Error(codes.Unauthenticated, "authentication failed with Basic authorization scheme")
+		return ctx, verifierError("Basic", ErrInvalidCredentials)
 	}
 
-	if outCtx, u, ok := basicAuth(ctx, authString[0], authString[1]); ok {
-		outCtx = context.WithValue(outCtx, Username, u)
-		outCtx = context.WithValue(outCtx, Online, true)
-
-		return outCtx, nil
+	outCtx, u, err := basicAuth(ctx, authString[0], authString[1])
+	if err != nil {
+		return ctx, verifierError("Basic", err)
 	}
 
-	return ctx, status.Error(codes.Unauthenticated, "authentication failed with Basic authorization scheme")
+	return newContextWithAuthenticated(ctx, outCtx, "Basic", u, true), nil
 }
 
-func verifyAuthBearer(ctx context.Context, bearerAuth AuthVerifyBearerFunc, token string) (context.Context, error) {
-	if outCtx, u, online, ok := bearerAuth(ctx, token); ok {
-		outCtx = context.WithValue(outCtx, Username, u)
-		outCtx = context.WithValue(outCtx, Online, online)
+func verifyAuthBearer(ctx context.Context, bearerAuth AuthVerifyBearerErrFunc, token string) (context.Context, error) {
+	return verifyToken(ctx, bearerAuth, "Bearer", token)
+}
 
-		return outCtx, nil
+// verifyToken verifies a token presented without a proof of possession (eg. the Bearer scheme or a custom header),
+// tokens bound to a DPoP key are rejected as they must be presented with a proof using the DPoP scheme (RFC 9449
+// section 7.1).
+func verifyToken(
+	ctx context.Context,
+	verify AuthVerifyBearerErrFunc,
+	scheme, token string,
+) (context.Context, error) {
+	outCtx, u, online, err := verify(ctx, token)
+	if err != nil {
+		return ctx, verifierError(scheme, err)
 	}
 
-	return ctx, status.Error(codes.Unauthenticated, "authentication failed with Bearer authorization scheme")
+	if _, bound := principalConfirmation(outCtx, "jkt"); bound {
+		return ctx, verifierError(scheme, fmt.Errorf("%w: token is bound to a DPoP key", ErrInvalidCredentials))
+	}
+
+	return newContextWithAuthenticated(ctx, outCtx, scheme, u, online), nil
 }
 
 // VerifyAuthorizationFunc returns a function that can be used to verify the authentication on a gRPC request.
 //
-//nolint:mnd // expected set length based on format.
+// It is equivalent to calling VerifyAuthenticatorsFunc with the built-in Basic and Bearer authenticators.
 func VerifyAuthorizationFunc(
 	basicAuth AuthVerifyBasicFunc,
 	bearerAuth AuthVerifyBearerFunc,
 ) func(ctx context.Context) (context.Context, error) {
-	return func(ctx context.Context) (context.Context, error) {
-		for _, auth := range getHeadersFromContext(ctx) {
-			if re.MatchString(auth) {
-				r := re.FindStringSubmatch(auth)
-				if len(r) >= 3 {
-					switch strings.ToLower(r[1]) {
-					case "basic":
-						return verifyAuthBasic(ctx, basicAuth, r[2])
-					case "bearer":
-						return verifyAuthBearer(ctx, bearerAuth, r[2])
-					}
-				}
-			}
-		}
-
-		return ctx, status.Errorf(codes.Unauthenticated, "authentication missing")
-	}
+	return VerifyAuthenticatorsFunc(
+		NewBasicAuthenticator(basicAuth),
+		NewBearerAuthenticator(bearerAuth),
+	)
+}
+
+// VerifyAuthorizationErrFunc returns a function that can be used to verify the authentication on a gRPC request
+// using verification functions that return errors.
+//
+// It is equivalent to calling VerifyAuthenticatorsFunc with NewBasicErrAuthenticator and NewBearerErrAuthenticator.
+func VerifyAuthorizationErrFunc(
+	basicAuth AuthVerifyBasicErrFunc,
+	bearerAuth AuthVerifyBearerErrFunc,
+) func(ctx context.Context) (context.Context, error) {
+	return VerifyAuthenticatorsFunc(
+		NewBasicErrAuthenticator(basicAuth),
+		NewBearerErrAuthenticator(bearerAuth),
+	)
 }